@@ -12,10 +12,12 @@ import (
 )
 
 var (
-	ApiToken   string = os.Getenv("API_TOKEN")
-	ErrJson           = errors.New("can't unmarshal JSON")
-	Lucas      *PlayerInfo
-	fieldNames = map[string]string{
+	ApiToken    string = os.Getenv("API_TOKEN")
+	ErrJson            = errors.New("can't unmarshal JSON")
+	ErrNotFound        = errors.New("resource not found")
+	Lucas       *PlayerInfo
+	Players     []*PlayerInfo // every tracked player, Lucas included, read through TrackedPlayers
+	fieldNames  = map[string]string{
 		"ChampLevel":                  "Niveau",
 		"VisionScore":                 "Score de vision",
 		"LongestTimeSpentLiving":      "Plus longue durée passée en vie",
@@ -40,6 +42,7 @@ func GetRiotApi(url string) ([]byte, error) {
 	req.Header.Add("X-Riot-Token", ApiToken)
 
 	client := &http.Client{}
+	Limiter.Wait()
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -47,6 +50,9 @@ func GetRiotApi(url string) ([]byte, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		errmsg := "Unexpected status code: " + strconv.Itoa(resp.StatusCode)
 		return nil, errors.New(errmsg)
@@ -81,9 +87,13 @@ func GetLucasStats() (string, error) {
 	return s, nil
 }
 
-func GetMatchMetaString(match *Match) (string, error) {
+func GetMatchMetaString(match *Match, info *PlayerInfo) (string, error) {
+	return getMatchMetaString(match, info, DefaultReportOptions.Roast)
+}
+
+func getMatchMetaString(match *Match, info *PlayerInfo, roast int) (string, error) {
 	playerIdx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
-		return p.Puuid == Lucas.PUUID
+		return p.Puuid == info.PUUID
 	})
 	if playerIdx == -1 {
 		return "", errors.New("couldn't find player's index")
@@ -91,30 +101,48 @@ func GetMatchMetaString(match *Match) (string, error) {
 	player := match.Info.Participants[playerIdx]
 
 	s := "🚨Nouvelle game! 🚨\n"
-	if player.Win {
+	if player.Win && roast >= 2 {
 		s += "- Victoire🎉 (on va quand même te trash mon con)\n"
+	} else if player.Win {
+		s += "- Victoire🎉\n"
 	} else {
 		s += "- Défaite\n"
 	}
 
+	s += fmt.Sprintf("- File: %s\n", GetQueueName(match.Info.QueueID))
 	s += fmt.Sprintf("- Champ: %s (%s)\n", player.ChampionName, player.IndividualPosition)
 	s += fmt.Sprintf("- %d/%d/%d (KDA: %.2f)\n", player.Kills, player.Deaths, player.Assists, player.Challenges.Kda)
+	if mastery, err := getMatchMasteryString(match, info, &player); err == nil {
+		s += mastery
+	}
 
 	return s, nil
 }
 
-func GetMatchStatsString(match *Match) (string, error) {
-	computed, err := ComputeStats(match, Lucas.PUUID)
+func GetMatchStatsString(match *Match, info *PlayerInfo) (string, error) {
+	return getMatchStatsString(match, info, DefaultReportOptions.Roast)
+}
+
+// Worst stats of the player: none without roasting, only the worst one at
+// roast 1, every bad ratio at MaxRoast
+func getMatchStatsString(match *Match, info *PlayerInfo, roast int) (string, error) {
+	if roast == 0 {
+		return "", nil
+	}
+	computed, err := ComputeStats(match, info.PUUID)
 	if err != nil {
 		return "Error getting stats of game " + match.Metadata.MatchID, err
 	}
 
 	minSlice := getMins(computed)
+	if roast == 1 && len(minSlice) > 1 {
+		minSlice = minSlice[:1]
+	}
 	str := "Pires stats de la game: 🫵\n"
 	for _, stat := range minSlice {
 		str += fmt.Sprintf("* %s:: %.2f (Moyenne de l'équipe: %.2f, Moyenne de la game: %.2f)\n", fieldNames[stat.name], stat.playerStat, stat.teamStats.avg, stat.gameStats.avg)
 	}
-	if len(minSlice) < 4 {
+	if (roast == 2 && len(minSlice) < 4) || roast >= MaxRoast {
 		badSlice := getBadRatios(computed)
 		for _, stat := range badSlice {
 			if !SliceContains(minSlice, stat) {
@@ -127,17 +155,8 @@ func GetMatchStatsString(match *Match) (string, error) {
 }
 
 // Message that will be sent by the bot:
-func GetMatchDescString(match *Match) (string, error) {
-	meta, err := GetMatchMetaString(match)
-	if err != nil {
-		return "Error gettting match stats: " + err.Error(), err
-	}
-	stats, err := GetMatchStatsString(match)
-	if err != nil {
-		return "Error gettting match stats: " + err.Error(), err
-	}
-
-	return fmt.Sprintf("%s%s", meta, stats), nil
+func GetMatchDescString(match *Match, info *PlayerInfo) (string, error) {
+	return GetMatchReport(match, info, DefaultReportOptions)
 }
 
 func Api() (string, error) {
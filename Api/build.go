@@ -0,0 +1,139 @@
+package api
+
+/* Helpers to describe a player's items, runes and summoner spells */
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	static "github.com/Nvim/silverstalker/Static"
+)
+
+var (
+	// Used when the static data bundle doesn't know the item
+	bootsIDs = []int{1001, 2422, 3005, 3006, 3009, 3010, 3013, 3020, 3047, 3111, 3117, 3158}
+	// Champions that can't buy boots
+	bootlessChampions = []string{"Cassiopeia"}
+)
+
+// Final items of the player, trinket excluded, empty slots skipped
+func (p *Participant) Items() []int {
+	items := make([]int, 0, 6)
+	for _, item := range []int{p.Item0, p.Item1, p.Item2, p.Item3, p.Item4, p.Item5} {
+		if item != 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (p *Participant) perkStyle(description string) (PerkStyle, bool) {
+	idx := slices.IndexFunc(p.Perks.Styles, func(s PerkStyle) bool {
+		return s.Description == description
+	})
+	if idx == -1 {
+		return PerkStyle{}, false
+	}
+	return p.Perks.Styles[idx], true
+}
+
+func isBoots(gameVersion string, item int) bool {
+	if boots, known := static.ItemHasTag(gameVersion, item, "Boots"); known {
+		return boots
+	}
+	return slices.Contains(bootsIDs, item)
+}
+
+// Whether the player held boots at the timestamp (ms), following the
+// purchases, sales and upgrades of the timeline
+func hasBootsAt(timeline *Timeline, match *Match, player *Participant, timestamp int64) bool {
+	held := 0
+	for _, frame := range timeline.Info.Frames {
+		for _, e := range frame.Events {
+			if e.Timestamp > timestamp {
+				return held > 0
+			}
+			if e.ParticipantID != player.ParticipantID {
+				continue
+			}
+			switch {
+			case e.Type == "ITEM_PURCHASED" && isBoots(match.Info.GameVersion, e.ItemID):
+				held++
+			case (e.Type == "ITEM_SOLD" || e.Type == "ITEM_DESTROYED") && isBoots(match.Info.GameVersion, e.ItemID):
+				held--
+			case e.Type == "ITEM_UNDO" && isBoots(match.Info.GameVersion, e.BeforeID):
+				held--
+			case e.Type == "ITEM_UNDO" && isBoots(match.Info.GameVersion, e.AfterID):
+				held++
+			}
+		}
+	}
+	return held > 0
+}
+
+// Remarks about questionable build choices
+func getBuildOddities(match *Match, player *Participant) []string {
+	oddities := make([]string, 0)
+
+	// Final items don't tell what the player had at 30 minutes, the check is
+	// skipped without the timeline
+	if match.Info.GameDuration >= 30*60 && !slices.Contains(bootlessChampions, player.ChampionName) {
+		if timeline, err := GetMatchTimeline(match.Metadata.MatchID); err == nil && !hasBootsAt(timeline, match, player, 30*60*1000) {
+			oddities = append(oddities, "Pas de bottes après 30 minutes de jeu 🦶")
+		}
+	}
+	if player.VisionWardsBoughtInGame == 0 {
+		oddities = append(oddities, "Zéro pink achetée 🙈")
+	}
+	if len(player.Items()) == 0 {
+		oddities = append(oddities, "Inventaire vide")
+	}
+	return oddities
+}
+
+func GetMatchBuildString(match *Match, info *PlayerInfo) (string, error) {
+	return getMatchBuildString(match, info, static.Lang(""), DefaultReportOptions.Roast)
+}
+
+// Names are in the given locale, oddities are left out without roasting
+func getMatchBuildString(match *Match, info *PlayerInfo, lang static.Lang, roast int) (string, error) {
+	playerIdx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
+		return p.Puuid == info.PUUID
+	})
+	if playerIdx == -1 {
+		return "", errors.New("couldn't find player's index")
+	}
+	player := &match.Info.Participants[playerIdx]
+	version := match.Info.GameVersion
+
+	s := "Build:\n"
+	items := make([]string, 0, 6)
+	for _, item := range player.Items() {
+		items = append(items, lang.ItemName(version, item))
+	}
+	if len(items) > 0 {
+		s += "- Objets: " + strings.Join(items, ", ") + "\n"
+	}
+	if player.Item6 != 0 {
+		s += "- Trinket: " + lang.ItemName(version, player.Item6) + "\n"
+	}
+
+	if primary, ok := player.perkStyle("primaryStyle"); ok && len(primary.Selections) > 0 {
+		s += fmt.Sprintf("- Runes: %s (%s)", lang.RuneName(version, primary.Selections[0].Perk), lang.RuneName(version, primary.Style))
+		if sub, ok := player.perkStyle("subStyle"); ok {
+			s += " + " + lang.RuneName(version, sub.Style)
+		}
+		s += "\n"
+	}
+	s += fmt.Sprintf("- Sorts: %s / %s\n", lang.SummonerSpellName(version, player.Summoner1ID), lang.SummonerSpellName(version, player.Summoner2ID))
+
+	if roast == 0 {
+		return s, nil
+	}
+	for _, oddity := range getBuildOddities(match, player) {
+		s += "* " + oddity + "\n"
+	}
+	return s, nil
+}
@@ -0,0 +1,203 @@
+package api
+
+/* Cache of Riot API responses, in memory and on disk */
+
+import (
+	"container/list"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long responses stay valid. 0 means forever: those are also written to disk.
+const (
+	MatchTTL    time.Duration = 0
+	TimelineTTL time.Duration = 0
+	AccountTTL                = 24 * time.Hour
+	LeagueTTL                 = 5 * time.Minute
+	MasteryTTL                = 10 * time.Minute
+)
+
+type cacheEntry struct {
+	key     string
+	data    []byte
+	expires time.Time // zero if the entry never expires
+}
+
+// Least recently used cache of raw responses, backed by a directory for
+// immutable ones
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	dir      string
+	order    *list.List // most recently used first
+	entries  map[string]*list.Element
+}
+
+var (
+	CacheDir      = cacheDir() // also holds the local stores (histories, snapshots)
+	ResponseCache = NewCache(512, CacheDir)
+)
+
+func cacheDir() string {
+	if dir := os.Getenv("CACHE_DIR"); dir != "" {
+		return dir
+	}
+	return "cache"
+}
+
+// Moves the cache and local stores to another directory
+func SetCacheDir(dir string) {
+	CacheDir = dir
+	ResponseCache = NewCache(512, dir)
+}
+
+// Creates a cache holding at most capacity entries in memory. Immutable entries
+// are stored under dir, disk storage is disabled if dir is empty.
+func NewCache(capacity int, dir string) *Cache {
+	return &Cache{
+		capacity: capacity,
+		dir:      dir,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Keys look like "match/EUW1_123": the part before the slash is the subdirectory
+func (c *Cache) path(key string) string {
+	kind, name, _ := strings.Cut(key, "/")
+	name = strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(name)
+	return filepath.Join(c.dir, kind, name+".json")
+}
+
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if entry.expires.IsZero() || time.Now().Before(entry.expires) {
+			c.order.MoveToFront(elem)
+			return entry.data, true
+		}
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	if c.dir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	c.add(key, data, 0)
+	return data, true
+}
+
+// Stores data for ttl, immutable data (ttl == 0) is written to disk too
+func (c *Cache) Set(key string, data []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.add(key, data, ttl)
+	if ttl != 0 || c.dir == "" {
+		return nil
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Whether immutable data is stored on disk for the key
+func (c *Cache) Stored(key string) bool {
+	if c.dir == "" {
+		return false
+	}
+	_, err := os.Stat(c.path(key))
+	return err == nil
+}
+
+// Removes the key from memory and disk
+func (c *Cache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+	if c.dir == "" {
+		return nil
+	}
+	err := os.Remove(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Removes every key starting with prefix, which must contain the slash
+func (c *Cache) DeletePrefix(prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.order.Remove(elem)
+			delete(c.entries, key)
+		}
+	}
+	if c.dir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(strings.TrimSuffix(c.path(prefix), ".json") + "*.json")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cache) add(key string, data []byte, ttl time.Duration) {
+	var expires time.Time
+	if ttl != 0 {
+		expires = time.Now().Add(ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &cacheEntry{key, data, expires}
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key, data, expires})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Performs a GET request on the given URL unless the response is cached
+func GetRiotApiCached(url string, key string, ttl time.Duration) ([]byte, error) {
+	if data, ok := ResponseCache.Get(key); ok {
+		return data, nil
+	}
+	data, err := GetRiotApi(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := ResponseCache.Set(key, data, ttl); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,189 @@
+package api
+
+/* Aggregated stats of a player on each champion, from the stored matches */
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Restricts aggregations to some matches, zero values don't filter
+type StatsFilter struct {
+	Queue int
+	From  time.Time
+	To    time.Time // exclusive
+}
+
+// Exclusive upper bound including the whole day of the YYYY-MM-DD date
+func ParseUntil(date string) (time.Time, error) {
+	day, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.AddDate(0, 0, 1), nil
+}
+
+type ChampionStats struct {
+	Champion   string
+	Games      int
+	Wins       int
+	Kills      int
+	Deaths     int
+	Assists    int
+	CS         int
+	Damage     int
+	Minutes    float64
+	scoreSum   float64
+	scoreGames int // games with a performance score
+}
+
+var championSorts = map[string]func(a, b *ChampionStats) float64{
+	"games":   func(a, b *ChampionStats) float64 { return float64(a.Games - b.Games) },
+	"winrate": func(a, b *ChampionStats) float64 { return a.Winrate() - b.Winrate() },
+	"kda":     func(a, b *ChampionStats) float64 { return a.Kda() - b.Kda() },
+	"cs":      func(a, b *ChampionStats) float64 { return a.CsPerMinute() - b.CsPerMinute() },
+	"damage":  func(a, b *ChampionStats) float64 { return a.DamagePerMinute() - b.DamagePerMinute() },
+	"score":   func(a, b *ChampionStats) float64 { return a.Score() - b.Score() },
+}
+
+func (f StatsFilter) Match(match *Match) bool {
+	if f.Queue != 0 && match.Info.QueueID != f.Queue {
+		return false
+	}
+	start := time.UnixMilli(match.Info.GameCreation)
+	if !f.From.IsZero() && start.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !start.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+func (c *ChampionStats) Winrate() float64 {
+	return float64(c.Wins) / float64(c.Games) * 100
+}
+
+func (c *ChampionStats) Kda() float64 {
+	return float64(c.Kills+c.Assists) / float64(max(c.Deaths, 1))
+}
+
+func (c *ChampionStats) CsPerMinute() float64 {
+	return float64(c.CS) / c.Minutes
+}
+
+func (c *ChampionStats) DamagePerMinute() float64 {
+	return float64(c.Damage) / c.Minutes
+}
+
+// Average performance score, 0 if no game could be scored
+func (c *ChampionStats) Score() float64 {
+	if c.scoreGames == 0 {
+		return 0
+	}
+	return c.scoreSum / float64(c.scoreGames)
+}
+
+// How the player did compared to the rest of the game: 100 is the game's
+// average on the computed stats
+func PerformanceScore(match *Match, puuid string) (float64, error) {
+	computed, err := ComputeStats(match, puuid)
+	if err != nil {
+		return 0, err
+	}
+	sum, count := 0.0, 0
+	for _, stat := range computed.stats {
+		if math.IsNaN(stat.GameRatio) || math.IsInf(stat.GameRatio, 0) {
+			continue
+		}
+		sum += stat.GameRatio
+		count++
+	}
+	if count == 0 {
+		return 0, errors.New("no stat to compute a score from")
+	}
+	return sum / float64(count) * 100, nil
+}
+
+func GetChampionStats(matches []*Match, puuid string, filter StatsFilter) []*ChampionStats {
+	byChampion := make(map[string]*ChampionStats)
+	for _, match := range matches {
+		if !filter.Match(match) {
+			continue
+		}
+		playerIdx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
+			return p.Puuid == puuid
+		})
+		if playerIdx == -1 {
+			continue
+		}
+		player := match.Info.Participants[playerIdx]
+
+		stats, ok := byChampion[player.ChampionName]
+		if !ok {
+			stats = &ChampionStats{Champion: player.ChampionName}
+			byChampion[player.ChampionName] = stats
+		}
+		stats.Games++
+		if player.Win {
+			stats.Wins++
+		}
+		stats.Kills += player.Kills
+		stats.Deaths += player.Deaths
+		stats.Assists += player.Assists
+		stats.CS += player.TotalMinionsKilled + player.NeutralMinionsKilled
+		stats.Damage += player.TotalDamageDealtToChampions
+		stats.Minutes += float64(match.Info.GameDuration) / 60
+		if score, err := PerformanceScore(match, puuid); err == nil {
+			stats.scoreSum += score
+			stats.scoreGames++
+		}
+	}
+
+	champions := make([]*ChampionStats, 0, len(byChampion))
+	for _, stats := range byChampion {
+		champions = append(champions, stats)
+	}
+	_ = SortChampionStats(champions, "games")
+	return champions
+}
+
+// Sorts by decreasing games, winrate, kda, cs, damage or score
+func SortChampionStats(champions []*ChampionStats, by string) error {
+	compare, ok := championSorts[strings.ToLower(by)]
+	if !ok {
+		return errors.New("unknown sort: " + by)
+	}
+	slices.SortStableFunc(champions, func(a, b *ChampionStats) int {
+		diff := compare(b, a)
+		switch {
+		case diff < 0:
+			return -1
+		case diff > 0:
+			return 1
+		}
+		return strings.Compare(a.Champion, b.Champion)
+	})
+	return nil
+}
+
+// Message listing the player's champions as a table
+func GetChampionStatsString(info *PlayerInfo, champions []*ChampionStats) string {
+	if len(champions) == 0 {
+		return "Aucune game stockée pour " + info.RiotID()
+	}
+	s := "Champions de " + info.RiotID() + ":\n```\n"
+	s += fmt.Sprintf("%-14s %5s %6s %5s %6s %6s %5s\n", "Champion", "Games", "WR", "KDA", "CS/m", "Dmg/m", "Score")
+	for i, c := range champions {
+		if i == 20 {
+			s += fmt.Sprintf("... et %d autres\n", len(champions)-i)
+			break
+		}
+		s += fmt.Sprintf("%-14.14s %5d %5.1f%% %5.2f %6.2f %6.0f %5.0f\n", c.Champion, c.Games, c.Winrate(), c.Kda(), c.CsPerMinute(), c.DamagePerMinute(), c.Score())
+	}
+	return s + "```"
+}
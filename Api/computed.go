@@ -4,6 +4,7 @@ import (
 	"errors"
 	"reflect"
 	"slices"
+	"strings"
 )
 
 var fields = map[string]bool{
@@ -39,6 +40,17 @@ type Stats struct {
 	GameRatio  float64 // ratio between game avg and players score
 }
 
+// Exported view of a Stats, for the HTTP API and dashboard
+type StatSummary struct {
+	Name      string  `json:"name"`
+	Label     string  `json:"label"`
+	Player    float64 `json:"player"`
+	TeamAvg   float64 `json:"teamAvg"`
+	GameAvg   float64 `json:"gameAvg"`
+	IsTeamMin bool    `json:"isTeamMin"`
+	IsGameMin bool    `json:"isGameMin"`
+}
+
 type MatchComputed struct {
 	stats map[string]Stats // TODO: generic type instead of hard-coded int
 }
@@ -67,15 +79,61 @@ func getBadRatios(match *MatchComputed) []Stats {
 	return slice
 }
 
+func (s *Stats) Summary() StatSummary {
+	return StatSummary{s.name, fieldNames[s.name], s.playerStat, s.teamStats.avg, s.gameStats.avg, s.isTeamMin, s.isGameMin}
+}
+
+// Stats where the player is the worst, completed with the ones under average
+// when there are few, as in the match report
+func GetWorstStats(match *Match, puuid string) ([]StatSummary, error) {
+	computed, err := ComputeStats(match, puuid)
+	if err != nil {
+		return nil, err
+	}
+	minSlice := getMins(computed)
+	worst := make([]StatSummary, 0, len(minSlice))
+	for _, stat := range minSlice {
+		worst = append(worst, stat.Summary())
+	}
+	if len(minSlice) < 4 {
+		for _, stat := range getBadRatios(computed) {
+			if !SliceContains(minSlice, stat) {
+				worst = append(worst, stat.Summary())
+			}
+		}
+	}
+	return worst, nil
+}
+
+// Every computed stat of the player, sorted by name
+func GetStatSummaries(match *Match, puuid string) ([]StatSummary, error) {
+	computed, err := ComputeStats(match, puuid)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]StatSummary, 0, len(computed.stats))
+	for _, stat := range computed.stats {
+		summaries = append(summaries, stat.Summary())
+	}
+	slices.SortFunc(summaries, func(a, b StatSummary) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return summaries, nil
+}
+
 func ComputeStats(match *Match, puiid string) (*MatchComputed, error) {
 	playerIdx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
-		return p.Puuid == Lucas.PUUID
+		return p.Puuid == puiid
 	})
 
 	if playerIdx == -1 {
 		return nil, errors.New("couldn't find player's PUID in participants list")
 	}
 
+	if len(match.Info.Participants) != 10 {
+		return nil, errors.New("stats can only be computed for 5v5 games")
+	}
+
 	player := match.Info.Participants[playerIdx]
 	participants := match.Info.Participants
 	statsMap := make(map[string]Stats)
@@ -0,0 +1,193 @@
+package api
+
+/* Expanded views of a match, shown on demand under reports. They only use the
+ * stored match and timeline. */
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Discord messages can't be longer
+const maxMessageLength = 2000
+
+func findParticipant(match *Match, puuid string) (*Participant, error) {
+	idx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
+		return p.Puuid == puuid
+	})
+	if idx == -1 {
+		return nil, errors.New("couldn't find player's index")
+	}
+	return &match.Info.Participants[idx], nil
+}
+
+func gameMinutes(match *Match) float64 {
+	return max(float64(match.Info.GameDuration)/60, 1)
+}
+
+func formatTimestamp(ms int64) string {
+	return fmt.Sprintf("%02d:%02d", ms/60000, ms/1000%60)
+}
+
+// Every computed stat of the player against their team and the whole game
+func GetFullStatsString(match *Match, puuid string) (string, error) {
+	player, err := findParticipant(match, puuid)
+	if err != nil {
+		return "", err
+	}
+	computed, err := ComputeStats(match, puuid)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(computed.stats))
+	for name := range computed.stats {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return fieldNames[names[i]] < fieldNames[names[j]] })
+
+	s := fmt.Sprintf("Stats de %s (%s):\n```\n", player.RiotIDGameName, player.ChampionName)
+	s += fmt.Sprintf("%-32s %10s %10s %10s\n", "", "Joueur", "Équipe", "Game")
+	for _, name := range names {
+		stat := computed.stats[name]
+		flag := ""
+		switch {
+		case stat.isGameMin:
+			flag = " 🫵"
+		case stat.isTeamMin:
+			flag = " 👎"
+		}
+		s += fmt.Sprintf("%-32.32s %10.2f %10.2f %10.2f%s\n", fieldNames[name], stat.playerStat, stat.teamStats.avg, stat.gameStats.avg, flag)
+	}
+	return s + "```", nil
+}
+
+// Gold, CS and level of the player every 5 minutes, then their kills and deaths
+func GetTimelineString(match *Match, timeline *Timeline, puuid string) (string, error) {
+	player, err := findParticipant(match, puuid)
+	if err != nil {
+		return "", err
+	}
+	names := make(map[int]string)
+	for _, p := range match.Info.Participants {
+		names[p.ParticipantID] = p.ChampionName
+	}
+	frameKey := fmt.Sprint(player.ParticipantID)
+
+	s := fmt.Sprintf("Timeline de %s (%s):\n```\n", player.RiotIDGameName, player.ChampionName)
+	frames := timeline.Info.Frames
+	for i, frame := range frames {
+		if i%5 != 0 && i != len(frames)-1 {
+			continue
+		}
+		f, ok := frame.ParticipantFrames[frameKey]
+		if !ok {
+			continue
+		}
+		s += fmt.Sprintf("%s  niv. %-2d %6d gold %4d CS\n", formatTimestamp(frame.Timestamp), f.Level, f.TotalGold, f.MinionsKilled+f.JungleMinionsKilled)
+	}
+	s += "```\n"
+
+	events := make([]string, 0)
+	for _, frame := range frames {
+		for _, e := range frame.Events {
+			if e.Type != "CHAMPION_KILL" {
+				continue
+			}
+			switch {
+			case e.KillerID == player.ParticipantID:
+				events = append(events, fmt.Sprintf("%s ⚔️ Kill sur %s", formatTimestamp(e.Timestamp), names[e.VictimID]))
+			case e.VictimID == player.ParticipantID:
+				events = append(events, fmt.Sprintf("%s 💀 Tué par %s", formatTimestamp(e.Timestamp), names[e.KillerID]))
+			case slices.Contains(e.AssistingParticipantIDs, player.ParticipantID):
+				events = append(events, fmt.Sprintf("%s 🤝 Assist sur %s", formatTimestamp(e.Timestamp), names[e.VictimID]))
+			}
+		}
+	}
+	for i, e := range events {
+		if len(s)+len(e)+20 > maxMessageLength {
+			s += fmt.Sprintf("... et %d autres\n", len(events)-i)
+			break
+		}
+		s += e + "\n"
+	}
+	return s, nil
+}
+
+// Enemy playing the same position as the player
+func getOpponent(match *Match, player *Participant) (*Participant, error) {
+	for i, p := range match.Info.Participants {
+		if p.TeamId != player.TeamId && p.TeamPosition != "" && p.TeamPosition == player.TeamPosition {
+			return &match.Info.Participants[i], nil
+		}
+	}
+	for i, p := range match.Info.Participants {
+		if p.TeamId != player.TeamId && p.IndividualPosition == player.IndividualPosition {
+			return &match.Info.Participants[i], nil
+		}
+	}
+	return nil, errors.New("couldn't find the player's opponent")
+}
+
+// The player against the enemy playing the same position
+func GetOpponentString(match *Match, puuid string) (string, error) {
+	player, err := findParticipant(match, puuid)
+	if err != nil {
+		return "", err
+	}
+	opponent, err := getOpponent(match, player)
+	if err != nil {
+		return "", err
+	}
+
+	minutes := gameMinutes(match)
+	rows := []struct {
+		label string
+		value func(p *Participant) float64
+	}{
+		{"KDA", func(p *Participant) float64 { return p.Challenges.Kda }},
+		{"Kills", func(p *Participant) float64 { return float64(p.Kills) }},
+		{"Morts", func(p *Participant) float64 { return -float64(p.Deaths) }},
+		{"Assists", func(p *Participant) float64 { return float64(p.Assists) }},
+		{"CS", func(p *Participant) float64 { return float64(p.TotalMinionsKilled + p.NeutralMinionsKilled) }},
+		{"CS/min", func(p *Participant) float64 { return float64(p.TotalMinionsKilled+p.NeutralMinionsKilled) / minutes }},
+		{"Gold", func(p *Participant) float64 { return float64(p.GoldEarned) }},
+		{"Dégâts", func(p *Participant) float64 { return float64(p.TotalDamageDealtToChampions) }},
+		{"Dégâts/min", func(p *Participant) float64 { return p.Challenges.DamagePerMinute }},
+		{"Vision", func(p *Participant) float64 { return float64(p.VisionScore) }},
+		{"Wards", func(p *Participant) float64 { return float64(p.WardsPlaced) }},
+		{"Niveau", func(p *Participant) float64 { return float64(p.ChampLevel) }},
+	}
+
+	s := fmt.Sprintf("%s (%s) contre %s (%s):\n```\n", player.RiotIDGameName, player.ChampionName, opponent.RiotIDGameName, opponent.ChampionName)
+	s += fmt.Sprintf("%-12s %10.10s   %10.10s\n", "", player.ChampionName, opponent.ChampionName)
+	won := 0
+	for _, row := range rows {
+		mine, theirs := row.value(player), row.value(opponent)
+		marks := [2]string{" ", " "}
+		switch {
+		case mine > theirs:
+			marks[0] = "✓"
+			won++
+		case mine < theirs:
+			marks[1] = "✓"
+		}
+		// Deaths are negated so that fewer is better
+		if row.label == "Morts" {
+			mine, theirs = -mine, -theirs
+		}
+		s += fmt.Sprintf("%-12s %10.10s %s %10.10s %s\n", row.label, formatStatValue(mine), marks[0], formatStatValue(theirs), marks[1])
+	}
+	s += "```\n"
+	s += fmt.Sprintf("%s gagne %d stats sur %d\n", player.RiotIDGameName, won, len(rows))
+	return s, nil
+}
+
+func formatStatValue(v float64) string {
+	if v == float64(int(v)) {
+		return fmt.Sprint(int(v))
+	}
+	return fmt.Sprintf("%.2f", v)
+}
@@ -0,0 +1,275 @@
+package api
+
+/* Helpers about games played together by several tracked players */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	static "github.com/Nvim/silverstalker/Static"
+)
+
+// Win/loss record of a group of tracked players in a queue
+type GroupRecord struct {
+	Players   []string `json:"players"` // Riot IDs of the group members
+	QueueID   int      `json:"queueId"`
+	Wins      int      `json:"wins"`
+	Losses    int      `json:"losses"`
+	LastMatch string   `json:"lastMatch"`
+}
+
+var (
+	GroupsFile string     = "groups.json"
+	Groups                = make(map[string]*GroupRecord)
+	groupsMu   sync.Mutex // guards Groups
+	queueNames = map[int]string{
+		420: "Solo/Duo",
+		440: "Flex",
+	}
+	// Stats compared between the members of a group:
+	groupFields = []string{
+		"Kda",
+		"DamagePerMinute",
+		"GoldPerMinute",
+		"VisionScore",
+		"TotalDamageDealtToChampions",
+	}
+)
+
+// Short name of ranked queues, full name from the static data otherwise
+func GetQueueName(queueID int) string {
+	if name, ok := queueNames[queueID]; ok {
+		return name
+	}
+	return static.QueueName(queueID)
+}
+
+func (g *GroupRecord) Winrate() float64 {
+	total := g.Wins + g.Losses
+	if total == 0 {
+		return 0
+	}
+	return float64(g.Wins) / float64(total) * 100
+}
+
+// Returns the tracked players who took part in the match
+func GetTrackedInMatch(match *Match, players []*PlayerInfo) []*PlayerInfo {
+	tracked := make([]*PlayerInfo, 0)
+	for _, p := range players {
+		if slices.Contains(match.Metadata.Participants, p.PUUID) {
+			tracked = append(tracked, p)
+		}
+	}
+	return tracked
+}
+
+// Splits the tracked players of the match by team, blue side first. Players
+// on opposite teams aren't a group.
+func SplitByTeam(match *Match, players []*PlayerInfo) [][]*PlayerInfo {
+	byTeam := make(map[int][]*PlayerInfo)
+	for _, info := range players {
+		idx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
+			return p.Puuid == info.PUUID
+		})
+		if idx != -1 {
+			teamID := match.Info.Participants[idx].TeamId
+			byTeam[teamID] = append(byTeam[teamID], info)
+		}
+	}
+	teamIDs := make([]int, 0, len(byTeam))
+	for teamID := range byTeam {
+		teamIDs = append(teamIDs, teamID)
+	}
+	slices.Sort(teamIDs)
+	teams := make([][]*PlayerInfo, 0, len(teamIDs))
+	for _, teamID := range teamIDs {
+		teams = append(teams, byTeam[teamID])
+	}
+	return teams
+}
+
+// Identifies a group in a queue regardless of the order of its players
+func groupKey(players []*PlayerInfo, queueID int) string {
+	puuids := make([]string, len(players))
+	for i, p := range players {
+		puuids[i] = p.PUUID
+	}
+	sort.Strings(puuids)
+	return strconv.Itoa(queueID) + ":" + strings.Join(puuids, ",")
+}
+
+func LoadGroups() error {
+	data, err := os.ReadFile(GroupsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &Groups); err != nil {
+		return ErrJson
+	}
+	return nil
+}
+
+func SaveGroups() error {
+	groupsMu.Lock()
+	defer groupsMu.Unlock()
+	return saveGroups()
+}
+
+func saveGroups() error {
+	data, err := json.MarshalIndent(Groups, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(GroupsFile, data, 0o644)
+}
+
+// Updates the record of the players' group with the result of the match, the
+// players must be on the same team
+func RecordGroupMatch(match *Match, players []*PlayerInfo) (*GroupRecord, error) {
+	if len(players) < 2 {
+		return nil, errors.New("a group needs at least 2 players")
+	}
+	if len(SplitByTeam(match, players)) != 1 {
+		return nil, errors.New("players of a group must be on the same team")
+	}
+	groupsMu.Lock()
+	defer groupsMu.Unlock()
+
+	key := groupKey(players, match.Info.QueueID)
+	group, ok := Groups[key]
+	if !ok {
+		group = &GroupRecord{QueueID: match.Info.QueueID}
+		for _, p := range players {
+			group.Players = append(group.Players, p.RiotID())
+		}
+		Groups[key] = group
+	}
+	if group.LastMatch == match.Metadata.MatchID {
+		return group, nil
+	}
+
+	playerIdx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
+		return p.Puuid == players[0].PUUID
+	})
+	if playerIdx == -1 {
+		return nil, errors.New("couldn't find player's index")
+	}
+	if match.Info.Participants[playerIdx].Win {
+		group.Wins++
+	} else {
+		group.Losses++
+	}
+	group.LastMatch = match.Metadata.MatchID
+
+	return group, saveGroups()
+}
+
+func getGroupFieldValue(participant *Participant, field string) float64 {
+	if fields[field] {
+		return getChallengeFieldInt(participant, field)
+	}
+	return getFieldInt(participant, field)
+}
+
+// Message sent when several tracked players were in the same game:
+func GetGroupMatchDescString(match *Match, players []*PlayerInfo) (string, error) {
+	return GetGroupMatchReport(match, players, DefaultReportOptions)
+}
+
+// Players on opposite teams get one section per team. The short template
+// leaves out the comparison, the worst player of each stat is only called out
+// when roasting.
+func GetGroupMatchReport(match *Match, players []*PlayerInfo, opts ReportOptions) (string, error) {
+	teams := SplitByTeam(match, players)
+	if len(teams) == 1 {
+		team, err := getTeamReport(match, teams[0], opts)
+		return "🚨Nouvelle game en groupe! 🚨\n" + team, err
+	}
+
+	s := "🚨Nouvelle game entre joueurs suivis, dans des équipes adverses! 🚨\n"
+	for _, team := range teams {
+		section, err := getTeamReport(match, team, opts)
+		if err != nil {
+			return "", err
+		}
+		s += "**Équipe " + getTeamName(match, team[0]) + "**\n" + section
+	}
+	return s, nil
+}
+
+func getTeamName(match *Match, player *PlayerInfo) string {
+	idx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
+		return p.Puuid == player.PUUID
+	})
+	if idx != -1 && match.Info.Participants[idx].TeamId == 200 {
+		return "rouge"
+	}
+	return "bleue"
+}
+
+// Results of the players of a team, compared when there are several of them
+func getTeamReport(match *Match, players []*PlayerInfo, opts ReportOptions) (string, error) {
+	group := make([]Participant, 0, len(players))
+	for _, info := range players {
+		playerIdx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
+			return p.Puuid == info.PUUID
+		})
+		if playerIdx == -1 {
+			return "", errors.New("couldn't find player's index")
+		}
+		group = append(group, match.Info.Participants[playerIdx])
+	}
+
+	s := ""
+	for _, p := range group {
+		result := "Défaite"
+		if p.Win {
+			result = "Victoire🎉"
+		}
+		s += fmt.Sprintf("- %s: %s (%s) %d/%d/%d - %s\n", p.RiotIDGameName, p.ChampionName, p.IndividualPosition, p.Kills, p.Deaths, p.Assists, result)
+	}
+
+	if len(group) < 2 {
+		return s, nil
+	}
+	if opts.Template != "short" {
+		s += "Comparaison du groupe:\n"
+		for _, field := range groupFields {
+			best, worst := 0, 0
+			for i := range group {
+				if getGroupFieldValue(&group[i], field) > getGroupFieldValue(&group[best], field) {
+					best = i
+				}
+				if getGroupFieldValue(&group[i], field) < getGroupFieldValue(&group[worst], field) {
+					worst = i
+				}
+			}
+			if opts.Roast == 0 {
+				s += fmt.Sprintf("* %s: 👑 %s (%.2f)\n", fieldNames[field], group[best].RiotIDGameName, getGroupFieldValue(&group[best], field))
+				continue
+			}
+			s += fmt.Sprintf("* %s: 👑 %s (%.2f) / 🫵 %s (%.2f)\n", fieldNames[field],
+				group[best].RiotIDGameName, getGroupFieldValue(&group[best], field),
+				group[worst].RiotIDGameName, getGroupFieldValue(&group[worst], field))
+		}
+	}
+
+	groupsMu.Lock()
+	record, ok := Groups[groupKey(players, match.Info.QueueID)]
+	groupsMu.Unlock()
+	if ok {
+		s += fmt.Sprintf("Bilan du groupe en %s: %dV/%dD (%.1f%%)\n", GetQueueName(record.QueueID), record.Wins, record.Losses, record.Winrate())
+	}
+
+	return s, nil
+}
@@ -0,0 +1,290 @@
+package api
+
+/* Local history of players' matches, filled by the poller and backfills */
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Filters of the match IDs endpoint, zero values are left out
+type MatchQuery struct {
+	Start     int
+	Count     int   // at most 100
+	StartTime int64 // epoch seconds
+	EndTime   int64 // epoch seconds
+	Queue     int
+	Type      string
+}
+
+// Match IDs of a player stored locally, and where their backfill stands
+type MatchHistory struct {
+	Puuid     string   `json:"puuid"`
+	MatchIDs  []string `json:"matchIds"`
+	NextStart int      `json:"nextStart"` // offset of the next page to list
+	EndTime   int64    `json:"endTime"`   // upper bound of the running backfill
+	Listed    bool     `json:"listed"`    // every match ID was listed
+	// Games played before this time (epoch seconds) were all backfilled
+	CompletedUntil int64 `json:"completedUntil"`
+}
+
+type BackfillProgress struct {
+	Listed     int // match IDs known
+	Downloaded int // matches stored, including previously stored ones
+	Failed     int
+}
+
+var (
+	historyMu sync.Mutex // guards history files
+	// Reads the player's stored matches, newest first and at most limit of
+	// them when limit > 0. Set once the database is opened, the history files
+	// are read until then.
+	MatchReader func(puuid string, filter StatsFilter, limit int) ([]*Match, error)
+)
+
+func (q MatchQuery) values() url.Values {
+	v := url.Values{}
+	v.Set("start", strconv.Itoa(q.Start))
+	if q.Count > 0 {
+		v.Set("count", strconv.Itoa(q.Count))
+	}
+	if q.StartTime > 0 {
+		v.Set("startTime", strconv.FormatInt(q.StartTime, 10))
+	}
+	if q.EndTime > 0 {
+		v.Set("endTime", strconv.FormatInt(q.EndTime, 10))
+	}
+	if q.Queue > 0 {
+		v.Set("queue", strconv.Itoa(q.Queue))
+	}
+	if q.Type != "" {
+		v.Set("type", q.Type)
+	}
+	return v
+}
+
+func (p *PlayerInfo) GetMatchIDs(q MatchQuery) (MatchID, error) {
+	if p.PUUID == "" {
+		return nil, errors.New("couldn't get player's matches: empty PUUID")
+	}
+	matchesURL := "https://europe.api.riotgames.com/lol/match/v5/matches/by-puuid/" + p.PUUID + "/ids?" + q.values().Encode()
+
+	res, err := GetRiotApi(matchesURL)
+	if err != nil {
+		return nil, err
+	}
+	var matchIDs MatchID
+	if err := json.Unmarshal(res, &matchIDs); err != nil {
+		return nil, err
+	}
+	return matchIDs, nil
+}
+
+func historyPath(puuid string) string {
+	return filepath.Join(CacheDir, "history", puuid+".json")
+}
+
+func loadMatchHistory(puuid string) (*MatchHistory, error) {
+	history := &MatchHistory{Puuid: puuid}
+	data, err := os.ReadFile(historyPath(puuid))
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, history); err != nil {
+		return nil, ErrJson
+	}
+	return history, nil
+}
+
+func (h *MatchHistory) save() error {
+	data, err := json.MarshalIndent(h, "", "\t")
+	if err != nil {
+		return err
+	}
+	path := historyPath(h.Puuid)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func (h *MatchHistory) add(matchIDs ...string) {
+	known := make(map[string]bool, len(h.MatchIDs))
+	for _, id := range h.MatchIDs {
+		known[id] = true
+	}
+	for _, id := range matchIDs {
+		if !known[id] {
+			known[id] = true
+			h.MatchIDs = append(h.MatchIDs, id)
+		}
+	}
+}
+
+func LoadMatchHistory(puuid string) (*MatchHistory, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	return loadMatchHistory(puuid)
+}
+
+// Adds matches to the player's local history
+func AddToHistory(puuid string, matchIDs ...string) error {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	history, err := loadMatchHistory(puuid)
+	if err != nil {
+		return err
+	}
+	history.add(matchIDs...)
+	return history.save()
+}
+
+// Every stored match of the player
+func GetPlayerMatches(puuid string) ([]*Match, error) {
+	if MatchReader != nil {
+		return MatchReader(puuid, StatsFilter{}, 0)
+	}
+	history, err := LoadMatchHistory(puuid)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]*Match, 0, len(history.MatchIDs))
+	for _, id := range history.MatchIDs {
+		if !ResponseCache.Stored("match/" + id) {
+			continue
+		}
+		match, err := GetMatchInfo(id)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// The player's count latest stored matches, newest first. Match IDs grow with
+// time, so only those matches are read.
+func GetRecentPlayerMatches(puuid string, count int) ([]*Match, error) {
+	if MatchReader != nil {
+		return MatchReader(puuid, StatsFilter{}, count)
+	}
+	history, err := LoadMatchHistory(puuid)
+	if err != nil {
+		return nil, err
+	}
+	ids := slices.Clone(history.MatchIDs)
+	slices.SortFunc(ids, func(a, b string) int { return cmp.Compare(gameNumber(b), gameNumber(a)) })
+	matches := make([]*Match, 0, count)
+	for _, id := range ids {
+		if len(matches) == count {
+			break
+		}
+		if !ResponseCache.Stored("match/" + id) {
+			continue
+		}
+		match, err := GetMatchInfo(id)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// "EUW1_7012345678" -> 7012345678
+func gameNumber(matchID string) int64 {
+	_, number, _ := strings.Cut(matchID, "_")
+	n, _ := strconv.ParseInt(number, 10, 64)
+	return n
+}
+
+// Lists the player's whole match history and downloads every match missing
+// locally. Interrupted backfills resume where they stopped.
+func (p *PlayerInfo) Backfill(progress func(BackfillProgress)) (BackfillProgress, error) {
+	var status BackfillProgress
+	historyMu.Lock()
+	history, err := loadMatchHistory(p.PUUID)
+	historyMu.Unlock()
+	if err != nil {
+		return status, err
+	}
+
+	/* List match IDs, page by page: */
+	if history.EndTime == 0 {
+		// Fix the upper bound so that new games don't shift pages
+		history.EndTime = time.Now().Unix()
+	}
+	for !history.Listed {
+		matchIDs, err := p.GetMatchIDs(MatchQuery{
+			Start:     history.NextStart,
+			Count:     100,
+			StartTime: history.CompletedUntil,
+			EndTime:   history.EndTime,
+		})
+		if err != nil {
+			return status, err
+		}
+		historyMu.Lock()
+		// Reload to keep matches added by the poller meanwhile
+		current, err := loadMatchHistory(p.PUUID)
+		if err == nil {
+			current.add(matchIDs...)
+			current.NextStart = history.NextStart + len(matchIDs)
+			current.EndTime = history.EndTime
+			current.Listed = len(matchIDs) < 100
+			err = current.save()
+			history = current
+		}
+		historyMu.Unlock()
+		if err != nil {
+			return status, err
+		}
+		status.Listed = len(history.MatchIDs)
+		if progress != nil {
+			progress(status)
+		}
+	}
+	status.Listed = len(history.MatchIDs)
+
+	/* Download missing matches: */
+	for _, id := range history.MatchIDs {
+		if !ResponseCache.Stored("match/" + id) {
+			if _, err := GetMatchInfo(id); err != nil {
+				status.Failed++
+				continue
+			}
+		}
+		status.Downloaded++
+		if progress != nil && status.Downloaded%20 == 0 {
+			progress(status)
+		}
+	}
+
+	// Next backfill only lists games played since this one
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	current, err := loadMatchHistory(p.PUUID)
+	if err != nil {
+		return status, err
+	}
+	if status.Failed == 0 {
+		current.CompletedUntil = current.EndTime
+		current.NextStart = 0
+		current.EndTime = 0
+		current.Listed = false
+	}
+	return status, current.save()
+}
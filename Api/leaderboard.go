@@ -0,0 +1,211 @@
+package api
+
+/* Ranking of tracked players on a stat over a period */
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LeaderboardEntry struct {
+	Player  *PlayerInfo
+	Value   float64
+	Display string
+	Games   int
+}
+
+// Stats computed over the player's games, besides rank and the catalogue stats
+var leaderboardStats = map[string]string{
+	"rank":    "Rang",
+	"winrate": "Winrate",
+	"kda":     "KDA",
+	"score":   "Score de performance",
+	"games":   "Games jouées",
+}
+
+// Start of the ranked season, SEASON_START (YYYY-MM-DD) or January 1st
+var SeasonStart = seasonStart()
+
+func seasonStart() time.Time {
+	if start, err := time.ParseInLocation(time.DateOnly, os.Getenv("SEASON_START"), time.Local); err == nil {
+		return start
+	}
+	return time.Date(time.Now().Year(), time.January, 1, 0, 0, 0, 0, time.Local)
+}
+
+// Period from "day", "week", "month", "season", "all" or a number of days like "7d"
+func ParsePeriod(period string) (StatsFilter, string, error) {
+	now := time.Now()
+	switch strings.ToLower(period) {
+	case "", "all":
+		return StatsFilter{}, "depuis le début", nil
+	case "season":
+		return StatsFilter{From: SeasonStart}, "depuis le début de la saison", nil
+	case "day":
+		return StatsFilter{From: now.AddDate(0, 0, -1)}, "sur 24h", nil
+	case "week":
+		return StatsFilter{From: now.AddDate(0, 0, -7)}, "sur 7 jours", nil
+	case "month":
+		return StatsFilter{From: now.AddDate(0, -1, 0)}, "sur 1 mois", nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(period), "d"))
+	if err != nil || days <= 0 {
+		return StatsFilter{}, "", errors.New("période invalide: " + period)
+	}
+	return StatsFilter{From: now.AddDate(0, 0, -days)}, fmt.Sprintf("sur %d jours", days), nil
+}
+
+// Name of the stat as understood by GetLeaderboard, case insensitive
+func resolveLeaderboardStat(stat string) (string, error) {
+	if stat == "" {
+		return "rank", nil
+	}
+	if _, ok := leaderboardStats[strings.ToLower(stat)]; ok {
+		return strings.ToLower(stat), nil
+	}
+	for field := range fields {
+		if strings.EqualFold(field, stat) {
+			return field, nil
+		}
+	}
+	return "", errors.New("stat inconnue: " + stat)
+}
+
+func getStatLabel(stat string) string {
+	if label, ok := leaderboardStats[stat]; ok {
+		return label
+	}
+	return fieldNames[stat]
+}
+
+// Ranks the players on the stat, best first. Players without games in the
+// period are left out, except for the rank.
+func GetLeaderboard(players []*PlayerInfo, stat string, filter StatsFilter) ([]LeaderboardEntry, error) {
+	stat, err := resolveLeaderboardStat(stat)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(players))
+	for _, p := range players {
+		if stat == "rank" {
+			league, err := GetRankedStatsByPuuid(p.PUUID)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, LeaderboardEntry{p, float64(league.RankValue()), league.String(), league.Wins + league.Losses})
+			continue
+		}
+
+		matches, err := GetPlayerMatches(p.PUUID)
+		if err != nil {
+			return nil, err
+		}
+		entry, ok := getLeaderboardEntry(p, matches, stat, filter)
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	slices.SortStableFunc(entries, func(a, b LeaderboardEntry) int {
+		switch {
+		case a.Value > b.Value:
+			return -1
+		case a.Value < b.Value:
+			return 1
+		}
+		return 0
+	})
+	return entries, nil
+}
+
+func getLeaderboardEntry(info *PlayerInfo, matches []*Match, stat string, filter StatsFilter) (LeaderboardEntry, bool) {
+	entry := LeaderboardEntry{Player: info}
+	var wins, kills, deaths, assists, scored int
+	var sum float64
+	for _, match := range matches {
+		if !filter.Match(match) || match.IsRemake() {
+			continue
+		}
+		idx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
+			return p.Puuid == info.PUUID
+		})
+		if idx == -1 {
+			continue
+		}
+		player := &match.Info.Participants[idx]
+		entry.Games++
+		if player.Win {
+			wins++
+		}
+		kills += player.Kills
+		deaths += player.Deaths
+		assists += player.Assists
+
+		switch stat {
+		case "score":
+			if score, err := PerformanceScore(match, info.PUUID); err == nil {
+				sum += score
+				scored++
+			}
+		case "winrate", "kda", "games":
+		default:
+			sum += getGroupFieldValue(player, stat)
+			scored++
+		}
+	}
+	if entry.Games == 0 {
+		return entry, false
+	}
+
+	switch stat {
+	case "games":
+		entry.Value = float64(entry.Games)
+		entry.Display = strconv.Itoa(entry.Games)
+	case "winrate":
+		entry.Value = float64(wins) / float64(entry.Games) * 100
+		entry.Display = fmt.Sprintf("%.1f%% (%dV/%dD)", entry.Value, wins, entry.Games-wins)
+	case "kda":
+		entry.Value = float64(kills+assists) / float64(max(deaths, 1))
+		entry.Display = fmt.Sprintf("%.2f", entry.Value)
+	default:
+		if scored == 0 {
+			return entry, false
+		}
+		entry.Value = sum / float64(scored)
+		entry.Display = fmt.Sprintf("%.2f", entry.Value)
+	}
+	return entry, true
+}
+
+func GetLeaderboardTitle(stat string, periodLabel string) string {
+	stat, err := resolveLeaderboardStat(stat)
+	if err != nil {
+		return err.Error()
+	}
+	if stat == "rank" {
+		return "Classement: " + getStatLabel(stat)
+	}
+	return fmt.Sprintf("Classement: %s %s", getStatLabel(stat), periodLabel)
+}
+
+func GetLeaderboardString(stat string, periodLabel string, entries []LeaderboardEntry) string {
+	s := "🏆 " + GetLeaderboardTitle(stat, periodLabel) + "\n"
+	if len(entries) == 0 {
+		return s + "Aucune game sur la période\n"
+	}
+	medals := []string{"🥇", "🥈", "🥉"}
+	for i, e := range entries {
+		place := strconv.Itoa(i+1) + "."
+		if i < len(medals) {
+			place = medals[i]
+		}
+		s += fmt.Sprintf("%s %s: %s (%d games)\n", place, e.Player.GameName, e.Display, e.Games)
+	}
+	return s
+}
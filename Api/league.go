@@ -0,0 +1,92 @@
+package api
+
+/* Rank snapshots of tracked players, taken after each game */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+type LeagueSnapshot struct {
+	Time         int64  `json:"time"` // epoch seconds
+	Tier         string `json:"tier"`
+	Rank         string `json:"rank"`
+	LeaguePoints int    `json:"leaguePoints"`
+	Wins         int    `json:"wins"`
+	Losses       int    `json:"losses"`
+}
+
+var (
+	tiers = []string{"IRON", "BRONZE", "SILVER", "GOLD", "PLATINUM", "EMERALD", "DIAMOND", "MASTER", "GRANDMASTER", "CHALLENGER"}
+	// Divisions from lowest to highest
+	divisions = []string{"IV", "III", "II", "I"}
+	leagueMu  sync.Mutex // guards league snapshot files, written before the database
+	// Reads the player's rank snapshots, set like MatchReader
+	LeagueReader func(puuid string) ([]LeagueSnapshot, error)
+)
+
+// Single number ordering ranks: 100 per division, LP on top. Apex tiers share
+// one base since their LP keep adding up from Master to Challenger.
+func RankValue(tier string, rank string, lp int) int {
+	tierIdx := slices.Index(tiers, tier)
+	if tierIdx == -1 {
+		return 0
+	}
+	if master := slices.Index(tiers, "MASTER"); tierIdx >= master {
+		return master*len(divisions)*100 + lp
+	}
+	divisionIdx := max(slices.Index(divisions, rank), 0)
+	return (tierIdx*len(divisions)+divisionIdx)*100 + lp
+}
+
+func (l *LeagueStats) RankValue() int {
+	return RankValue(l.Tier, l.Rank, l.LeaguePoints)
+}
+
+func (l *LeagueStats) String() string {
+	return fmt.Sprintf("%s %s %d LP", l.Tier, l.Rank, l.LeaguePoints)
+}
+
+func (s *LeagueSnapshot) RankValue() int {
+	return RankValue(s.Tier, s.Rank, s.LeaguePoints)
+}
+
+func leagueSnapshotsPath(puuid string) string {
+	return filepath.Join(CacheDir, "league-snapshots", puuid+".json")
+}
+
+func loadLeagueSnapshots(puuid string) ([]LeagueSnapshot, error) {
+	var snapshots []LeagueSnapshot
+	data, err := os.ReadFile(leagueSnapshotsPath(puuid))
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshots, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, ErrJson
+	}
+	return snapshots, nil
+}
+
+// Stored Solo/Duo rank snapshots of the player, oldest first. Snapshots are
+// taken by storage.SaveLeagueSnapshot.
+func LoadLeagueSnapshots(puuid string) ([]LeagueSnapshot, error) {
+	if LeagueReader != nil {
+		return LeagueReader(puuid)
+	}
+	return LoadLeagueSnapshotsFile(puuid)
+}
+
+// Rank snapshots of the player's file, even once LeagueReader is set
+func LoadLeagueSnapshotsFile(puuid string) ([]LeagueSnapshot, error) {
+	leagueMu.Lock()
+	defer leagueMu.Unlock()
+	return loadLeagueSnapshots(puuid)
+}
@@ -0,0 +1,211 @@
+package api
+
+/* Helpers to fetch champion masteries and keep snapshots of them */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	static "github.com/Nvim/silverstalker/Static"
+)
+
+type ChampionMastery struct {
+	Puuid                        string `json:"puuid"`
+	ChampionID                   int    `json:"championId"`
+	ChampionLevel                int    `json:"championLevel"`
+	ChampionPoints               int    `json:"championPoints"`
+	LastPlayTime                 int64  `json:"lastPlayTime"` // epoch milliseconds
+	ChampionPointsSinceLastLevel int    `json:"championPointsSinceLastLevel"`
+	ChampionPointsUntilNextLevel int    `json:"championPointsUntilNextLevel"`
+}
+
+// Masteries of a player that changed at some point in time, the first
+// snapshot has all of them
+type MasterySnapshot struct {
+	Time      int64             `json:"time"` // epoch seconds
+	Masteries []ChampionMastery `json:"masteries"`
+}
+
+var (
+	// Period over which progress is shown
+	MasteryProgressWindow = 30 * 24 * time.Hour
+	snapshotsMu           sync.Mutex // guards snapshot files
+)
+
+// Mastery of a player on a champion, nil if the player never played it
+func GetChampionMastery(puuid string, championID int) (*ChampionMastery, error) {
+	url := "https://euw1.api.riotgames.com/lol/champion-mastery/v4/champion-masteries/by-puuid/" + puuid + "/by-champion/" + strconv.Itoa(championID)
+
+	res, err := GetRiotApiCached(url, "mastery/"+puuid+"_"+strconv.Itoa(championID), MasteryTTL)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var mastery ChampionMastery
+	if err := json.Unmarshal(res, &mastery); err != nil {
+		return nil, err
+	}
+	return &mastery, nil
+}
+
+// Every mastery of the player, highest points first
+func GetChampionMasteries(puuid string) ([]ChampionMastery, error) {
+	url := "https://euw1.api.riotgames.com/lol/champion-mastery/v4/champion-masteries/by-puuid/" + puuid
+
+	res, err := GetRiotApiCached(url, "masteries/"+puuid, MasteryTTL)
+	if err != nil {
+		return nil, err
+	}
+	var masteries []ChampionMastery
+	if err := json.Unmarshal(res, &masteries); err != nil {
+		return nil, err
+	}
+	return masteries, nil
+}
+
+func snapshotsPath(puuid string) string {
+	return filepath.Join(CacheDir, "mastery-snapshots", puuid+".json")
+}
+
+// Stored mastery snapshots of the player, oldest first
+func LoadMasterySnapshots(puuid string) ([]MasterySnapshot, error) {
+	snapshotsMu.Lock()
+	defer snapshotsMu.Unlock()
+	return loadMasterySnapshots(puuid)
+}
+
+func loadMasterySnapshots(puuid string) ([]MasterySnapshot, error) {
+	var snapshots []MasterySnapshot
+	data, err := os.ReadFile(snapshotsPath(puuid))
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshots, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &snapshots); err != nil {
+		return nil, ErrJson
+	}
+	return snapshots, nil
+}
+
+// Mastery of the player on the champion at the time (epoch seconds), replayed
+// from the snapshots. The zero mastery if the champion wasn't played by then,
+// false if the snapshots start later.
+func masteryAt(snapshots []MasterySnapshot, championID int, t int64) (ChampionMastery, bool) {
+	var mastery ChampionMastery
+	if len(snapshots) == 0 || snapshots[0].Time > t {
+		return mastery, false
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Time > t {
+			break
+		}
+		if idx := slices.IndexFunc(snapshot.Masteries, func(m ChampionMastery) bool { return m.ChampionID == championID }); idx != -1 {
+			mastery = snapshot.Masteries[idx]
+		}
+	}
+	return mastery, true
+}
+
+// Fetches the player's masteries and stores the ones that changed since the
+// last snapshot
+func SaveMasterySnapshot(puuid string) error {
+	masteries, err := GetChampionMasteries(puuid)
+	if err != nil {
+		return err
+	}
+
+	snapshotsMu.Lock()
+	defer snapshotsMu.Unlock()
+	snapshots, err := loadMasterySnapshots(puuid)
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	changed := make([]ChampionMastery, 0)
+	for _, m := range masteries {
+		if before, ok := masteryAt(snapshots, m.ChampionID, now); !ok || before.ChampionPoints != m.ChampionPoints {
+			changed = append(changed, m)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	snapshots = append(snapshots, MasterySnapshot{now, changed})
+
+	data, err := json.Marshal(snapshots)
+	if err != nil {
+		return err
+	}
+	path := snapshotsPath(puuid)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Points the player had on the champion at the start of the window, or when
+// the snapshots start if later
+func pointsAtWindowStart(snapshots []MasterySnapshot, championID int) (int, bool) {
+	if len(snapshots) == 0 {
+		return 0, false
+	}
+	since := max(time.Now().Add(-MasteryProgressWindow).Unix(), snapshots[0].Time)
+	mastery, ok := masteryAt(snapshots, championID, since)
+	return mastery.ChampionPoints, ok
+}
+
+// Mastery line of the match report, mentions first games on a champion. Only
+// a snapshot from before the game tells whether it was the first one. Reads
+// the snapshots saved by the poller, never the Riot API.
+func getMatchMasteryString(match *Match, info *PlayerInfo, player *Participant) (string, error) {
+	snapshots, err := LoadMasterySnapshots(info.PUUID)
+	if err != nil {
+		return "", err
+	}
+	if before, ok := masteryAt(snapshots, player.ChampionID, match.Info.GameStartTimestamp/1000); ok && before.ChampionPoints == 0 {
+		return fmt.Sprintf("- 🆕 Première game sur %s!\n", player.ChampionName), nil
+	}
+
+	mastery, ok := masteryAt(snapshots, player.ChampionID, time.Now().Unix())
+	if !ok || mastery.ChampionPoints == 0 {
+		return "", errors.New("no stored mastery on the champion")
+	}
+	return fmt.Sprintf("- Maîtrise: niveau %d (%d pts)\n", mastery.ChampionLevel, mastery.ChampionPoints), nil
+}
+
+// Message listing the player's best champions and their recent progress
+func GetMasteryString(info *PlayerInfo, count int) (string, error) {
+	masteries, err := GetChampionMasteries(info.PUUID)
+	if err != nil {
+		return "", err
+	}
+	snapshots, err := LoadMasterySnapshots(info.PUUID)
+	if err != nil {
+		return "", err
+	}
+
+	s := "Maîtrises de " + info.RiotID() + ":\n```\n"
+	for i, m := range masteries {
+		if i == count {
+			break
+		}
+		s += fmt.Sprintf("%-14.14s niv. %-3d %9d pts", static.ChampionName("", m.ChampionID), m.ChampionLevel, m.ChampionPoints)
+		if before, ok := pointsAtWindowStart(snapshots, m.ChampionID); ok && m.ChampionPoints > before {
+			s += fmt.Sprintf("  (+%d en %d jours)", m.ChampionPoints-before, int(MasteryProgressWindow.Hours()/24))
+		}
+		s += "\n"
+	}
+	return s + "```", nil
+}
@@ -22,6 +22,20 @@ type Challenge struct {
 	TeamDamagePercentage      float64 `json:"teamDamagePercentage"`
 }
 
+type PerkSelection struct {
+	Perk int `json:"perk"`
+}
+
+type PerkStyle struct {
+	Description string          `json:"description"` // "primaryStyle" or "subStyle"
+	Style       int             `json:"style"`
+	Selections  []PerkSelection `json:"selections"`
+}
+
+type Perks struct {
+	Styles []PerkStyle `json:"styles"`
+}
+
 type Participant struct {
 	Lane                        string    `json:"lane"`
 	RiotIDTagline               string    `json:"riotIdTagline"`
@@ -30,6 +44,7 @@ type Participant struct {
 	RiotIDGameName              string    `json:"riotIdGameName"`
 	ChampionName                string    `json:"championName"`
 	IndividualPosition          string    `json:"individualPosition"`
+	TeamPosition                string    `json:"teamPosition"`
 	Kills                       int       `json:"kills"`
 	Assists                     int       `json:"assists"`
 	Deaths                      int       `json:"deaths"`
@@ -59,22 +74,36 @@ type Participant struct {
 	Summoner2ID                 int       `json:"summoner2Id"`
 	TotalDamageDealtToChampions int       `json:"totalDamageDealtToChampions"`
 	TotalMinionsKilled          int       `json:"totalMinionsKilled"`
+	NeutralMinionsKilled        int       `json:"neutralMinionsKilled"`
 	TotalTimeSpentDead          int       `json:"totalTimeSpentDead"`
 	TurretKills                 int       `json:"turretKills"`
 	VisionScore                 int       `json:"visionScore"`
+	Item0                       int       `json:"item0"`
+	Item1                       int       `json:"item1"`
+	Item2                       int       `json:"item2"`
+	Item3                       int       `json:"item3"`
+	Item4                       int       `json:"item4"`
+	Item5                       int       `json:"item5"`
+	Item6                       int       `json:"item6"` // trinket
+	Perks                       Perks     `json:"perks"`
 	Win                         bool      `json:"win"`
+	GameEndedInEarlySurrender   bool      `json:"gameEndedInEarlySurrender"` // remake
 }
 
 type MatchInfo struct {
-	EndOfGameResult  string        `json:"endOfGameResult"`
-	GameType         string        `json:"gameType"`
-	GameName         string        `json:"gameName"`
-	Participants     []Participant `json:"participants"`
-	GameID           int64         `json:"gameId"`
-	QueueID          int           `json:"queueId"`
-	GameCreation     int64         `json:"gameCreation"`
-	GameDuration     int           `json:"gameDuration"`
-	GameEndTimestamp int64         `json:"gameEndTimestamp"`
+	EndOfGameResult    string        `json:"endOfGameResult"`
+	GameType           string        `json:"gameType"`
+	GameName           string        `json:"gameName"`
+	GameMode           string        `json:"gameMode"`
+	GameVersion        string        `json:"gameVersion"`
+	Participants       []Participant `json:"participants"`
+	GameID             int64         `json:"gameId"`
+	QueueID            int           `json:"queueId"`
+	MapID              int           `json:"mapId"`
+	GameCreation       int64         `json:"gameCreation"`
+	GameDuration       int           `json:"gameDuration"`
+	GameStartTimestamp int64         `json:"gameStartTimestamp"`
+	GameEndTimestamp   int64         `json:"gameEndTimestamp"`
 }
 
 type Match struct {
@@ -85,7 +114,7 @@ type Match struct {
 func GetMatchInfo(id string) (*Match, error) {
 	url := "https://europe.api.riotgames.com/lol/match/v5/matches/" + id
 
-	res, err := GetRiotApi(url)
+	res, err := GetRiotApiCached(url, "match/"+id, MatchTTL)
 	if err != nil {
 		return nil, err
 	}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"slices"
+	"strings"
 )
 
 type PlayerInfo struct {
@@ -14,6 +15,7 @@ type PlayerInfo struct {
 	PUUID      string
 	SummonerID string
 	AccountID  string
+	Guild      string `json:",omitempty"` // guild that started tracking them, empty for the group's own players
 }
 
 type AccountJSON struct {
@@ -49,6 +51,29 @@ type LeagueStats struct {
 
 type MatchID []string
 
+// Builds a PlayerInfo from a "GameName#TagLine" Riot ID
+func ParseRiotID(riotID string) (*PlayerInfo, error) {
+	gameName, tagLine, found := strings.Cut(strings.TrimSpace(riotID), "#")
+	if !found || gameName == "" || tagLine == "" {
+		return nil, errors.New("invalid Riot ID, expected GameName#TagLine: " + riotID)
+	}
+	return &PlayerInfo{GameName: gameName, TagLine: tagLine}, nil
+}
+
+// Tracked player with the given Riot ID, nil if there is none
+func FindPlayer(riotID string) *PlayerInfo {
+	for _, p := range TrackedPlayers() {
+		if strings.EqualFold(p.RiotID(), strings.TrimSpace(riotID)) {
+			return p
+		}
+	}
+	return nil
+}
+
+func (p *PlayerInfo) RiotID() string {
+	return p.GameName + "#" + p.TagLine
+}
+
 func (p *PlayerInfo) GetIDs() error {
 	if p.GameName == "" || p.TagLine == "" {
 		err := errors.New("couldn't retrieve player's IDs without GameName and TagLine")
@@ -57,7 +82,7 @@ func (p *PlayerInfo) GetIDs() error {
 	puidUrl := "https://europe.api.riotgames.com/riot/account/v1/accounts/by-riot-id/" + p.GameName + "/" + p.TagLine
 
 	var puidResponse AccountJSON
-	res, err := GetRiotApi(puidUrl)
+	res, err := GetRiotApiCached(puidUrl, "account/"+p.RiotID(), AccountTTL)
 	if err != nil {
 		return err
 	}
@@ -67,7 +92,7 @@ func (p *PlayerInfo) GetIDs() error {
 
 	var summonerResponse SummonerJSON
 	summonerUrl := "https://euw1.api.riotgames.com/lol/summoner/v4/summoners/by-puuid/" + puidResponse.Puuid
-	res, err = GetRiotApi(summonerUrl)
+	res, err = GetRiotApiCached(summonerUrl, "summoner/"+puidResponse.Puuid, AccountTTL)
 	if err != nil {
 		return err
 	}
@@ -81,6 +106,23 @@ func (p *PlayerInfo) GetIDs() error {
 	return nil // No errors :)
 }
 
+// Current profile icon of the player, bypassing the cache
+func (p *PlayerInfo) GetProfileIconID() (int, error) {
+	if err := ResponseCache.Delete("summoner/" + p.PUUID); err != nil {
+		return 0, err
+	}
+	summonerUrl := "https://euw1.api.riotgames.com/lol/summoner/v4/summoners/by-puuid/" + p.PUUID
+	res, err := GetRiotApiCached(summonerUrl, "summoner/"+p.PUUID, AccountTTL)
+	if err != nil {
+		return 0, err
+	}
+	var summoner SummonerJSON
+	if err := json.Unmarshal(res, &summoner); err != nil {
+		return 0, err
+	}
+	return summoner.ProfileIconID, nil
+}
+
 func (p *PlayerInfo) getRankedStats() (rankedStats *LeagueStats, err error) {
 	if p.SummonerID == "" {
 		err = errors.New("couldn't get info about player: empty SummonerID")
@@ -88,9 +130,18 @@ func (p *PlayerInfo) getRankedStats() (rankedStats *LeagueStats, err error) {
 	}
 
 	statsUrl := "https://euw1.api.riotgames.com/lol/league/v4/entries/by-summoner/" + p.SummonerID
+	return getSoloQueueStats(statsUrl, "league/"+p.SummonerID)
+}
+
+// Solo/Duo league stats of any player, tracked or not
+func GetRankedStatsByPuuid(puuid string) (*LeagueStats, error) {
+	statsUrl := "https://euw1.api.riotgames.com/lol/league/v4/entries/by-puuid/" + puuid
+	return getSoloQueueStats(statsUrl, "league/"+puuid)
+}
 
+func getSoloQueueStats(statsUrl string, cacheKey string) (rankedStats *LeagueStats, err error) {
 	var statsArray []LeagueStats
-	res, err := GetRiotApi(statsUrl)
+	res, err := GetRiotApiCached(statsUrl, cacheKey, LeagueTTL)
 	if err != nil {
 		return nil, err
 	}
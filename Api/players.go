@@ -0,0 +1,120 @@
+package api
+
+/* Persisted list of tracked players */
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	PlayersFile string     = "players.json"
+	playersMu   sync.Mutex // guards Players and PlayersFile
+)
+
+// Snapshot of the tracked players, safe to use while they're added or removed
+func TrackedPlayers() []*PlayerInfo {
+	playersMu.Lock()
+	defer playersMu.Unlock()
+	return slices.Clone(Players)
+}
+
+// Loads the tracked players. Without a players file, they are read from the
+// comma separated Riot IDs of TRACKED_PLAYERS and the file is created.
+func LoadPlayers() error {
+	playersMu.Lock()
+	defer playersMu.Unlock()
+
+	data, err := os.ReadFile(PlayersFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return initPlayers()
+	}
+	if err != nil {
+		return err
+	}
+	var players []*PlayerInfo
+	if err := json.Unmarshal(data, &players); err != nil {
+		return ErrJson
+	}
+	Players = players
+	updateLucas()
+	return nil
+}
+
+func initPlayers() error {
+	riotIDs := os.Getenv("TRACKED_PLAYERS")
+	if riotIDs == "" {
+		riotIDs = "lucxsstbn#EUW"
+	}
+	Players = nil
+	for _, riotID := range strings.Split(riotIDs, ",") {
+		player, err := ParseRiotID(riotID)
+		if err != nil {
+			return err
+		}
+		if err := player.GetIDs(); err != nil {
+			return err
+		}
+		Players = append(Players, player)
+	}
+	updateLucas()
+	return savePlayers()
+}
+
+// Lucas stays the first tracked player for the legacy commands
+func updateLucas() {
+	Lucas = nil
+	if len(Players) > 0 {
+		Lucas = Players[0]
+	}
+}
+
+func savePlayers() error {
+	data, err := json.MarshalIndent(Players, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(PlayersFile, data, 0o644)
+}
+
+// Resolves the player's IDs and starts tracking them. guildID is the guild
+// asking for it, empty from the CLI.
+func AddPlayer(riotID string, guildID string) (*PlayerInfo, error) {
+	player, err := ParseRiotID(riotID)
+	if err != nil {
+		return nil, err
+	}
+	player.Guild = guildID
+	if err := player.GetIDs(); err != nil {
+		return nil, err
+	}
+
+	playersMu.Lock()
+	defer playersMu.Unlock()
+	if slices.ContainsFunc(Players, func(p *PlayerInfo) bool { return p.PUUID == player.PUUID }) {
+		return nil, errors.New(player.RiotID() + " is already tracked")
+	}
+	Players = append(slices.Clone(Players), player)
+	updateLucas()
+	return player, savePlayers()
+}
+
+func RemovePlayer(riotID string) (*PlayerInfo, error) {
+	playersMu.Lock()
+	defer playersMu.Unlock()
+
+	idx := slices.IndexFunc(Players, func(p *PlayerInfo) bool {
+		return strings.EqualFold(p.RiotID(), strings.TrimSpace(riotID))
+	})
+	if idx == -1 {
+		return nil, errors.New(riotID + " isn't tracked")
+	}
+	player := Players[idx]
+	Players = slices.Delete(slices.Clone(Players), idx, idx+1)
+	updateLucas()
+	return player, savePlayers()
+}
@@ -0,0 +1,212 @@
+package api
+
+/* Players' consent to announcements about them, and deletion of their data */
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// What may be announced about a player
+const (
+	ConsentAll       = "all"       // everything, the default
+	ConsentWins      = "wins"      // reports of won games only
+	ConsentSummaries = "summaries" // reports without roasting
+	ConsentNone      = "none"      // nothing
+)
+
+// Kinds of announcements
+const (
+	LiveAnnouncement   = "live"   // game in progress
+	ReportAnnouncement = "report" // game report
+	RoastAnnouncement  = "roast"  // worst stats, build oddities, tilt alerts
+)
+
+var (
+	Consents    = []string{ConsentAll, ConsentWins, ConsentSummaries, ConsentNone}
+	PrivacyFile = "privacy.json"
+	consents    = make(map[string]string) // PUUID -> consent, ConsentAll if missing
+	privacyMu   sync.Mutex
+)
+
+func LoadPrivacy() error {
+	privacyMu.Lock()
+	defer privacyMu.Unlock()
+
+	data, err := os.ReadFile(PrivacyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]string)
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return ErrJson
+	}
+	consents = loaded
+	return nil
+}
+
+func savePrivacy() error {
+	data, err := json.MarshalIndent(consents, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(PrivacyFile, data, 0o644)
+}
+
+func GetConsent(puuid string) string {
+	privacyMu.Lock()
+	defer privacyMu.Unlock()
+	if consent, ok := consents[puuid]; ok {
+		return consent
+	}
+	return ConsentAll
+}
+
+func SetConsent(puuid string, consent string) error {
+	if !slices.Contains(Consents, consent) {
+		return errors.New("choix invalide: " + consent + " (" + strings.Join(Consents, ", ") + ")")
+	}
+	privacyMu.Lock()
+	defer privacyMu.Unlock()
+	if consent == ConsentAll {
+		delete(consents, puuid)
+	} else {
+		consents[puuid] = consent
+	}
+	return savePrivacy()
+}
+
+// Whether the player agreed to the kind of announcement. won only matters for reports.
+func Allows(puuid string, kind string, won bool) bool {
+	switch GetConsent(puuid) {
+	case ConsentAll:
+		return true
+	case ConsentWins:
+		return kind == ReportAnnouncement && won
+	case ConsentSummaries:
+		return kind == ReportAnnouncement
+	}
+	return false
+}
+
+// Players who agreed to the kind of announcement
+func AllowingPlayers(players []*PlayerInfo, kind string, won bool) []*PlayerInfo {
+	allowing := make([]*PlayerInfo, 0, len(players))
+	for _, p := range players {
+		if Allows(p.PUUID, kind, won) {
+			allowing = append(allowing, p)
+		}
+	}
+	return allowing
+}
+
+// Whether every tracked player of the match agreed to the kind of announcement
+// about it, as showing the whole game shows their stats too
+func AllAllowInMatch(match *Match, players []*PlayerInfo, kind string) bool {
+	return len(AllowingInMatch(match, players, kind)) == len(GetTrackedInMatch(match, players))
+}
+
+// Players of the match who agreed to the kind of announcement about it
+func AllowingInMatch(match *Match, players []*PlayerInfo, kind string) []*PlayerInfo {
+	allowing := make([]*PlayerInfo, 0, len(players))
+	for _, p := range GetTrackedInMatch(match, players) {
+		idx := slices.IndexFunc(match.Info.Participants, func(part Participant) bool { return part.Puuid == p.PUUID })
+		if Allows(p.PUUID, kind, match.Info.Participants[idx].Win) {
+			allowing = append(allowing, p)
+		}
+	}
+	return allowing
+}
+
+// Deletes the player's history, snapshots, cached API responses, group records
+// and consent, and the stored matches no other tracked player took part in.
+// The player should be untracked first so that nothing gets stored again.
+func DeletePlayerData(player *PlayerInfo) error {
+	puuid := player.PUUID
+	history, err := LoadMatchHistory(puuid)
+	if err != nil {
+		return err
+	}
+	shared := make(map[string]bool)
+	for _, p := range TrackedPlayers() {
+		if p.PUUID == puuid {
+			continue
+		}
+		other, err := LoadMatchHistory(p.PUUID)
+		if err != nil {
+			return err
+		}
+		for _, id := range other.MatchIDs {
+			shared[id] = true
+		}
+	}
+	for _, id := range history.MatchIDs {
+		if shared[id] {
+			continue
+		}
+		if err := ResponseCache.Delete("match/" + id); err != nil {
+			return err
+		}
+		if err := ResponseCache.Delete("timeline/" + id); err != nil {
+			return err
+		}
+	}
+
+	historyMu.Lock()
+	err = removeFile(historyPath(puuid))
+	historyMu.Unlock()
+	if err != nil {
+		return err
+	}
+	snapshotsMu.Lock()
+	err = removeFile(snapshotsPath(puuid))
+	snapshotsMu.Unlock()
+	if err != nil {
+		return err
+	}
+	leagueMu.Lock()
+	err = removeFile(leagueSnapshotsPath(puuid))
+	leagueMu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"league/" + puuid, "summoner/" + puuid, "masteries/" + puuid, "account/" + player.RiotID()} {
+		if err := ResponseCache.Delete(key); err != nil {
+			return err
+		}
+	}
+	if err := ResponseCache.DeletePrefix("mastery/" + puuid + "_"); err != nil {
+		return err
+	}
+
+	groupsMu.Lock()
+	for key, group := range Groups {
+		if strings.Contains(key, puuid) || slices.ContainsFunc(group.Players, func(riotID string) bool { return strings.EqualFold(riotID, player.RiotID()) }) {
+			delete(Groups, key)
+		}
+	}
+	err = saveGroups()
+	groupsMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	privacyMu.Lock()
+	defer privacyMu.Unlock()
+	delete(consents, puuid)
+	return savePrivacy()
+}
+
+func removeFile(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package api
+
+/* Request budget shared by every call to the Riot API */
+
+import (
+	"sync"
+	"time"
+)
+
+// Allows at most Limit requests in any Window
+type RateWindow struct {
+	Limit  int
+	Window time.Duration
+}
+
+type RateLimiter struct {
+	mu       sync.Mutex
+	windows  []RateWindow
+	requests []time.Time // send times of recent requests, oldest first
+}
+
+// Development keys allow 20 requests every second and 100 every 2 minutes
+var Limiter = NewRateLimiter(RateWindow{20, time.Second}, RateWindow{100, 2 * time.Minute})
+
+func NewRateLimiter(windows ...RateWindow) *RateLimiter {
+	return &RateLimiter{windows: windows}
+}
+
+// Returns how long to wait before the next request fits in every window
+func (l *RateLimiter) delay(now time.Time) time.Duration {
+	var delay time.Duration
+	for _, w := range l.windows {
+		if len(l.requests) < w.Limit {
+			continue
+		}
+		oldest := l.requests[len(l.requests)-w.Limit]
+		if d := oldest.Add(w.Window).Sub(now); d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
+
+// Blocks until a request can be sent without exceeding the budget
+func (l *RateLimiter) Wait() {
+	// Other callers may take the slot while this one sleeps, hence the retry
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		delay := l.delay(now)
+		if delay <= 0 {
+			l.requests = append(l.requests, now)
+			break
+		}
+		l.mu.Unlock()
+		time.Sleep(delay)
+	}
+	defer l.mu.Unlock()
+
+	// Only keep what the largest window needs
+	longest := 0
+	for _, w := range l.windows {
+		longest = max(longest, w.Limit)
+	}
+	if len(l.requests) > longest {
+		l.requests = l.requests[len(l.requests)-longest:]
+	}
+}
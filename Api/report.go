@@ -0,0 +1,62 @@
+package api
+
+/* How reports are rendered, chosen by each community */
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	static "github.com/Nvim/silverstalker/Static"
+)
+
+type ReportOptions struct {
+	Template   string // one of Templates
+	Roast      int    // from 0 (no roasting) to MaxRoast
+	Locale     string // Data Dragon locale of item, rune and spell names, static.Locale if empty
+	Scoreboard bool   // also post the scoreboard of the 10 participants
+	Card       bool   // attach a PNG summary card of each tracked player
+}
+
+const MaxRoast = 3
+
+var (
+	// "full": result, stats and build, "stats": no build, "short": result only
+	Templates            = []string{"full", "stats", "short"}
+	DefaultReportOptions = ReportOptions{Template: "full", Roast: 2, Card: true}
+)
+
+func (o ReportOptions) Validate() error {
+	if !slices.Contains(Templates, o.Template) {
+		return errors.New("template inconnu: " + o.Template + " (" + strings.Join(Templates, ", ") + ")")
+	}
+	if o.Roast < 0 || o.Roast > MaxRoast {
+		return fmt.Errorf("niveau de roast invalide: %d (0 à %d)", o.Roast, MaxRoast)
+	}
+	return nil
+}
+
+// Report of a game with a single tracked player
+func GetMatchReport(match *Match, info *PlayerInfo, opts ReportOptions) (string, error) {
+	s, err := getMatchMetaString(match, info, opts.Roast)
+	if err != nil {
+		return "Error gettting match stats: " + err.Error(), err
+	}
+	if opts.Template == "short" {
+		return s, nil
+	}
+	stats, err := getMatchStatsString(match, info, opts.Roast)
+	if err != nil {
+		return "Error gettting match stats: " + err.Error(), err
+	}
+	s += stats
+	if opts.Template == "stats" {
+		return s, nil
+	}
+	build, err := getMatchBuildString(match, info, static.Lang(opts.Locale), opts.Roast)
+	if err != nil {
+		return "Error gettting match build: " + err.Error(), err
+	}
+	return s + build, nil
+}
@@ -0,0 +1,106 @@
+package api
+
+/* Scoreboard of the 10 participants of a game */
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type ScoreboardRow struct {
+	Participant *Participant
+	Rank        string // short Solo/Duo rank, empty if unknown
+	Tracked     bool
+}
+
+var tierAbbreviations = map[string]string{
+	"IRON":        "I",
+	"BRONZE":      "B",
+	"SILVER":      "S",
+	"GOLD":        "G",
+	"PLATINUM":    "P",
+	"EMERALD":     "E",
+	"DIAMOND":     "D",
+	"MASTER":      "M",
+	"GRANDMASTER": "GM",
+	"CHALLENGER":  "C",
+}
+
+// "EMERALD II 45 LP" -> "E2 45LP", apex tiers have no division
+func (l *LeagueStats) ShortString() string {
+	tier, ok := tierAbbreviations[l.Tier]
+	if !ok {
+		return "?"
+	}
+	if slices.Index(tiers, l.Tier) < slices.Index(tiers, "MASTER") {
+		tier += fmt.Sprint(len(divisions) - slices.Index(divisions, l.Rank))
+	}
+	return fmt.Sprintf("%s %dLP", tier, l.LeaguePoints)
+}
+
+// Rows of both teams, blue side first. Current ranks are only fetched with
+// withRanks, they cost one request per participant.
+func GetScoreboard(match *Match, players []*PlayerInfo, withRanks bool) []ScoreboardRow {
+	rows := make([]ScoreboardRow, 0, len(match.Info.Participants))
+	for _, teamID := range []int{100, 200} {
+		for i, p := range match.Info.Participants {
+			if p.TeamId != teamID {
+				continue
+			}
+			row := ScoreboardRow{Participant: &match.Info.Participants[i]}
+			row.Tracked = slices.ContainsFunc(players, func(info *PlayerInfo) bool { return info.PUUID == p.Puuid })
+			if withRanks {
+				row.Rank = "-" // unranked or unavailable
+				if league, err := GetRankedStatsByPuuid(p.Puuid); err == nil {
+					row.Rank = league.ShortString()
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// Monospaced table, tracked players are marked with an arrow
+func GetScoreboardString(rows []ScoreboardRow) string {
+	withRanks := slices.ContainsFunc(rows, func(r ScoreboardRow) bool { return r.Rank != "" })
+
+	header := fmt.Sprintf("  %-12s %-11s %-8s %4s %6s %6s %3s", "Joueur", "Champion", "KDA", "CS", "Gold", "Dégâts", "Vis")
+	if withRanks {
+		header += fmt.Sprintf(" %-9s", "Rang")
+	}
+
+	s := "```\n"
+	team := 0
+	for _, row := range rows {
+		p := row.Participant
+		if p.TeamId != team {
+			team = p.TeamId
+			name := "Équipe bleue"
+			if team == 200 {
+				name = "Équipe rouge"
+			}
+			result := "Défaite"
+			if p.Win {
+				result = "Victoire"
+			}
+			if team == 200 {
+				s += "\n"
+			}
+			s += fmt.Sprintf("%s (%s)\n%s\n", name, result, strings.TrimRight(header, " "))
+		}
+		marker := " "
+		if row.Tracked {
+			marker = "►"
+		}
+		cs := p.TotalMinionsKilled + p.NeutralMinionsKilled
+		line := fmt.Sprintf("%s %-12.12s %-11.11s %-8s %4d %5.1fk %5.1fk %3d", marker, p.RiotIDGameName, p.ChampionName,
+			fmt.Sprintf("%d/%d/%d", p.Kills, p.Deaths, p.Assists), cs, float64(p.GoldEarned)/1000, float64(p.TotalDamageDealtToChampions)/1000, p.VisionScore)
+		if withRanks {
+			line += fmt.Sprintf(" %-9s", row.Rank)
+		}
+		s += strings.TrimRight(line, " ") + "\n"
+	}
+	return s + "```\n"
+}
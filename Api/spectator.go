@@ -0,0 +1,137 @@
+package api
+
+/* Helpers to fetch data about games in progress */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	static "github.com/Nvim/silverstalker/Static"
+)
+
+type CurrentGameParticipant struct {
+	Puuid      string `json:"puuid"`
+	RiotID     string `json:"riotId"`
+	ChampionID int    `json:"championId"`
+	TeamID     int    `json:"teamId"`
+	Spell1ID   int    `json:"spell1Id"`
+	Spell2ID   int    `json:"spell2Id"`
+	Bot        bool   `json:"bot"`
+}
+
+type CurrentGameInfo struct {
+	GameID            int64                    `json:"gameId"`
+	GameType          string                   `json:"gameType"`
+	GameMode          string                   `json:"gameMode"`
+	MapID             int                      `json:"mapId"`
+	PlatformID        string                   `json:"platformId"`
+	GameQueueConfigID int                      `json:"gameQueueConfigId"`
+	GameStartTime     int64                    `json:"gameStartTime"`
+	GameLength        int64                    `json:"gameLength"`
+	Participants      []CurrentGameParticipant `json:"participants"`
+}
+
+// ID of the match-v5 document the game will have once it ended
+func (g *CurrentGameInfo) MatchID() string {
+	return g.PlatformID + "_" + strconv.FormatInt(g.GameID, 10)
+}
+
+func (g *CurrentGameInfo) IsRanked() bool {
+	_, ok := queueNames[g.GameQueueConfigID]
+	return ok
+}
+
+// Game the player is currently in, nil if they aren't playing
+func (p *PlayerInfo) GetActiveGame() (*CurrentGameInfo, error) {
+	if p.PUUID == "" {
+		return nil, errors.New("couldn't get player's active game: empty PUUID")
+	}
+	url := "https://euw1.api.riotgames.com/lol/spectator/v5/active-games/by-summoner/" + p.PUUID
+
+	res, err := GetRiotApi(url)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var game CurrentGameInfo
+	if err := json.Unmarshal(res, &game); err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+func getLiveParticipantString(p *CurrentGameParticipant, tracked bool) string {
+	s := "- "
+	if tracked {
+		s += "⭐ "
+	}
+	s += fmt.Sprintf("%s: %s", p.RiotID, static.ChampionName("", p.ChampionID))
+
+	if rank, err := GetRankedStatsByPuuid(p.Puuid); err == nil {
+		s += fmt.Sprintf(" | %s %s %d LP", rank.Tier, rank.Rank, rank.LeaguePoints)
+	} else {
+		s += " | Unranked"
+	}
+
+	mastery, err := GetChampionMastery(p.Puuid, p.ChampionID)
+	switch {
+	case err != nil:
+	case mastery == nil:
+		s += " | Jamais joué"
+	default:
+		s += fmt.Sprintf(" | Maîtrise %d (%d pts)", mastery.ChampionLevel, mastery.ChampionPoints)
+	}
+	return s + "\n"
+}
+
+// Message sent when tracked players start a ranked game:
+// Players of the list who are in the game
+func GetTrackedInGame(game *CurrentGameInfo, players []*PlayerInfo) []*PlayerInfo {
+	tracked := make([]*PlayerInfo, 0)
+	for _, p := range players {
+		if slices.ContainsFunc(game.Participants, func(part CurrentGameParticipant) bool { return part.Puuid == p.PUUID }) {
+			tracked = append(tracked, p)
+		}
+	}
+	return tracked
+}
+
+func GetLiveGameString(game *CurrentGameInfo, players []*PlayerInfo) (string, error) {
+	tracked := make([]CurrentGameParticipant, 0)
+	for _, p := range game.Participants {
+		if slices.ContainsFunc(players, func(info *PlayerInfo) bool { return info.PUUID == p.Puuid }) {
+			tracked = append(tracked, p)
+		}
+	}
+	if len(tracked) == 0 {
+		return "", errors.New("no tracked player in game")
+	}
+
+	s := "🔴 En live! 🔴\n"
+	for _, p := range tracked {
+		s += fmt.Sprintf("%s vient de lancer une game classée (%s) sur %s\n", p.RiotID, GetQueueName(game.GameQueueConfigID), static.ChampionName("", p.ChampionID))
+	}
+
+	for _, teamID := range []int{100, 200} {
+		if teamID == 100 {
+			s += "Équipe bleue:\n"
+		} else {
+			s += "Équipe rouge:\n"
+		}
+		for _, p := range game.Participants {
+			if p.TeamID != teamID {
+				continue
+			}
+			isTracked := slices.ContainsFunc(tracked, func(t CurrentGameParticipant) bool { return t.Puuid == p.Puuid })
+			s += getLiveParticipantString(&p, isTracked)
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,77 @@
+package api
+
+/* One line summaries of a player's games */
+
+import (
+	"cmp"
+	"slices"
+)
+
+type MatchSummary struct {
+	MatchID      string  `json:"matchId"`
+	QueueID      int     `json:"queueId"`
+	GameCreation int64   `json:"gameCreation"` // epoch milliseconds
+	GameDuration int     `json:"gameDuration"` // seconds
+	Champion     string  `json:"champion"`
+	Position     string  `json:"position"`
+	Win          bool    `json:"win"`
+	Kills        int     `json:"kills"`
+	Deaths       int     `json:"deaths"`
+	Assists      int     `json:"assists"`
+	CS           int     `json:"cs"`
+	Damage       int     `json:"damage"`
+	VisionScore  int     `json:"visionScore"`
+	Score        float64 `json:"score"` // 0 when it can't be computed
+}
+
+func SummarizeMatch(match *Match, puuid string) (MatchSummary, bool) {
+	idx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
+		return p.Puuid == puuid
+	})
+	if idx == -1 {
+		return MatchSummary{}, false
+	}
+	p := match.Info.Participants[idx]
+	score, _ := PerformanceScore(match, puuid)
+	return MatchSummary{
+		MatchID:      match.Metadata.MatchID,
+		QueueID:      match.Info.QueueID,
+		GameCreation: match.Info.GameCreation,
+		GameDuration: match.Info.GameDuration,
+		Champion:     p.ChampionName,
+		Position:     p.IndividualPosition,
+		Win:          p.Win,
+		Kills:        p.Kills,
+		Deaths:       p.Deaths,
+		Assists:      p.Assists,
+		CS:           p.TotalMinionsKilled + p.NeutralMinionsKilled,
+		Damage:       p.TotalDamageDealtToChampions,
+		VisionScore:  p.VisionScore,
+		Score:        score,
+	}, true
+}
+
+// Summaries of the player's stored games, newest first. limit <= 0 returns all of them.
+func GetPlayerMatchSummaries(puuid string, filter StatsFilter, limit int) ([]MatchSummary, error) {
+	matches, err := GetPlayerMatches(puuid)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(matches, func(a, b *Match) int {
+		return cmp.Compare(b.Info.GameCreation, a.Info.GameCreation)
+	})
+
+	summaries := make([]MatchSummary, 0)
+	for _, match := range matches {
+		if limit > 0 && len(summaries) == limit {
+			break
+		}
+		if !filter.Match(match) {
+			continue
+		}
+		if summary, ok := SummarizeMatch(match, puuid); ok {
+			summaries = append(summaries, summary)
+		}
+	}
+	return summaries, nil
+}
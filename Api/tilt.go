@@ -0,0 +1,162 @@
+package api
+
+/* Detection of streaks and tilt from a player's stored matches */
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+type TiltThresholds struct {
+	LossStreak      int // consecutive losses
+	WinStreak       int // consecutive wins
+	HighDeaths      int // deaths making a game a "high death" one
+	HighDeathGames  int // high death games among the last HighDeathWindow ones
+	HighDeathWindow int
+	LateNightGames  int // games started at night in the last 24 hours
+	LateNightStart  int // hour
+	LateNightEnd    int // hour
+}
+
+// Games shorter than this are remakes and don't count
+const remakeDuration = 5 * 60
+
+// Recent games looked at, enough for every threshold
+const tiltWindow = 30
+
+func (m *Match) IsRemake() bool {
+	if m.Info.GameDuration < remakeDuration {
+		return true
+	}
+	return slices.ContainsFunc(m.Info.Participants, func(p Participant) bool {
+		return p.GameEndedInEarlySurrender
+	})
+}
+
+// Thresholds can be overridden with TILT_* environment variables
+var Thresholds = TiltThresholds{
+	LossStreak:      envInt("TILT_LOSS_STREAK", 4),
+	WinStreak:       envInt("TILT_WIN_STREAK", 5),
+	HighDeaths:      envInt("TILT_HIGH_DEATHS", 10),
+	HighDeathGames:  envInt("TILT_HIGH_DEATH_GAMES", 3),
+	HighDeathWindow: envInt("TILT_HIGH_DEATH_WINDOW", 5),
+	LateNightGames:  envInt("TILT_LATE_NIGHT_GAMES", 3),
+	LateNightStart:  envInt("TILT_LATE_NIGHT_START", 1),
+	LateNightEnd:    envInt("TILT_LATE_NIGHT_END", 6),
+}
+
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func (t TiltThresholds) isLateNight(start time.Time) bool {
+	hour := start.Local().Hour()
+	if t.LateNightStart <= t.LateNightEnd {
+		return hour >= t.LateNightStart && hour < t.LateNightEnd
+	}
+	return hour >= t.LateNightStart || hour < t.LateNightEnd
+}
+
+// The player's games, newest first, remakes left out
+func playerGames(matches []*Match, puuid string) []Participant {
+	sorted := slices.Clone(matches)
+	slices.SortFunc(sorted, func(a, b *Match) int {
+		return cmp.Compare(b.Info.GameCreation, a.Info.GameCreation)
+	})
+	games := make([]Participant, 0, len(sorted))
+	for _, match := range sorted {
+		if match.IsRemake() {
+			continue
+		}
+		idx := slices.IndexFunc(match.Info.Participants, func(p Participant) bool {
+			return p.Puuid == puuid
+		})
+		if idx != -1 {
+			games = append(games, match.Info.Participants[idx])
+		}
+	}
+	return games
+}
+
+// Alerts triggered by the player's latest game. Alerts repeat for each game
+// while their threshold stays reached.
+func GetTiltAlerts(matches []*Match, puuid string, t TiltThresholds) []string {
+	alerts := make([]string, 0)
+	games := playerGames(matches, puuid)
+	if len(games) == 0 {
+		return alerts
+	}
+
+	/* Streaks: */
+	streak := 1
+	for streak < len(games) && games[streak].Win == games[0].Win {
+		streak++
+	}
+	if !games[0].Win && t.LossStreak > 0 && streak >= t.LossStreak {
+		alerts = append(alerts, fmt.Sprintf("%d défaites d'affilée, va dormir 😴", streak))
+	}
+	if games[0].Win && t.WinStreak > 0 && streak >= t.WinStreak {
+		alerts = append(alerts, fmt.Sprintf("%d victoires d'affilée, qui joue sur ton compte? 🔥", streak))
+	}
+
+	/* Repeated high death games: */
+	if t.HighDeathGames > 0 && games[0].Deaths >= t.HighDeaths {
+		count := 0
+		for _, g := range games[:min(t.HighDeathWindow, len(games))] {
+			if g.Deaths >= t.HighDeaths {
+				count++
+			}
+		}
+		if count >= t.HighDeathGames {
+			alerts = append(alerts, fmt.Sprintf("%d games à %d morts ou plus sur les %d dernières, c'est du feed 💀", count, t.HighDeaths, min(t.HighDeathWindow, len(games))))
+		}
+	}
+
+	/* Late night games: */
+	sorted := slices.DeleteFunc(slices.Clone(matches), (*Match).IsRemake)
+	slices.SortFunc(sorted, func(a, b *Match) int {
+		return cmp.Compare(b.Info.GameCreation, a.Info.GameCreation)
+	})
+	latest := time.UnixMilli(sorted[0].Info.GameCreation)
+	if t.LateNightGames > 0 && t.isLateNight(latest) {
+		count := 0
+		for _, match := range sorted {
+			start := time.UnixMilli(match.Info.GameCreation)
+			if latest.Sub(start) > 24*time.Hour {
+				break
+			}
+			if t.isLateNight(start) {
+				count++
+			}
+		}
+		if count >= t.LateNightGames {
+			alerts = append(alerts, fmt.Sprintf("%d games en pleine nuit, il est %s, au lit 🛏️", count, latest.Local().Format("15h04")))
+		}
+	}
+	return alerts
+}
+
+// Message sent when the player's latest game triggered alerts, empty otherwise
+func GetTiltString(info *PlayerInfo, t TiltThresholds) (string, error) {
+	matches, err := GetRecentPlayerMatches(info.PUUID, tiltWindow)
+	if err != nil {
+		return "", err
+	}
+	alerts := GetTiltAlerts(matches, info.PUUID, t)
+	if len(alerts) == 0 {
+		return "", nil
+	}
+	s := "⚠️ Alerte " + info.GameName + " ⚠️\n"
+	for _, alert := range alerts {
+		s += "- " + alert + "\n"
+	}
+	return s, nil
+}
@@ -0,0 +1,63 @@
+package api
+
+/* Helpers to fetch the minute by minute timeline of matches */
+
+import (
+	"encoding/json"
+)
+
+type ParticipantFrame struct {
+	ParticipantID       int `json:"participantId"`
+	Level               int `json:"level"`
+	Xp                  int `json:"xp"`
+	CurrentGold         int `json:"currentGold"`
+	TotalGold           int `json:"totalGold"`
+	MinionsKilled       int `json:"minionsKilled"`
+	JungleMinionsKilled int `json:"jungleMinionsKilled"`
+}
+
+type TimelineEvent struct {
+	Type                    string `json:"type"`
+	Timestamp               int64  `json:"timestamp"`
+	ParticipantID           int    `json:"participantId"`
+	ItemID                  int    `json:"itemId"`
+	BeforeID                int    `json:"beforeId"` // item of an undone purchase or sale
+	AfterID                 int    `json:"afterId"`
+	KillerID                int    `json:"killerId"`
+	VictimID                int    `json:"victimId"`
+	AssistingParticipantIDs []int  `json:"assistingParticipantIds"`
+	TeamID                  int    `json:"teamId"`
+	MonsterType             string `json:"monsterType"`
+	BuildingType            string `json:"buildingType"`
+}
+
+type TimelineFrame struct {
+	Timestamp         int64                       `json:"timestamp"`
+	ParticipantFrames map[string]ParticipantFrame `json:"participantFrames"` // keyed by participant ID
+	Events            []TimelineEvent             `json:"events"`
+}
+
+type TimelineInfo struct {
+	FrameInterval int64           `json:"frameInterval"`
+	Frames        []TimelineFrame `json:"frames"`
+}
+
+type Timeline struct {
+	Metadata MatchMetadata `json:"metadata"`
+	Info     TimelineInfo  `json:"info"`
+}
+
+func GetMatchTimeline(id string) (*Timeline, error) {
+	url := "https://europe.api.riotgames.com/lol/match/v5/matches/" + id + "/timeline"
+
+	res, err := GetRiotApiCached(url, "timeline/"+id, TimelineTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	var timeline Timeline
+	if err := json.Unmarshal(res, &timeline); err != nil {
+		return nil, err
+	}
+	return &timeline, nil
+}
@@ -0,0 +1,59 @@
+package bot
+
+/* Command downloading a player's whole match history */
+
+import (
+	"fmt"
+	"log"
+
+	Api "github.com/Nvim/silverstalker/Api"
+	storage "github.com/Nvim/silverstalker/Storage"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Tracked player matching the Riot ID, looked up on Riot's side otherwise
+func resolvePlayer(riotID string) (*Api.PlayerInfo, error) {
+	if player := Api.FindPlayer(riotID); player != nil {
+		return player, nil
+	}
+	player, err := Api.ParseRiotID(riotID)
+	if err != nil {
+		return nil, err
+	}
+	if err := player.GetIDs(); err != nil {
+		return nil, err
+	}
+	return player, nil
+}
+
+func backfill(discord *discordgo.Session, channelID string, riotID string) {
+	player, err := resolvePlayer(riotID)
+	if err != nil {
+		_, _ = discord.ChannelMessageSend(channelID, "Joueur introuvable: "+err.Error())
+		return
+	}
+
+	status, err := discord.ChannelMessageSend(channelID, "Récupération de l'historique de "+player.RiotID()+"...")
+	if err != nil {
+		log.Println("couldn't send message in channel")
+		return
+	}
+	progress := func(p Api.BackfillProgress) {
+		msg := fmt.Sprintf("Historique de %s: %d games listées, %d téléchargées", player.RiotID(), p.Listed, p.Downloaded)
+		if _, err := discord.ChannelMessageEdit(channelID, status.ID, msg); err != nil {
+			log.Println("couldn't edit backfill progress: " + err.Error())
+		}
+	}
+
+	result, err := player.Backfill(progress)
+	if err := storage.ImportMatches(storage.Store, player.PUUID); err != nil {
+		log.Println("couldn't store backfilled games of " + player.RiotID() + ": " + err.Error())
+	}
+	msg := fmt.Sprintf("Historique de %s terminé: %d games stockées, %d échecs", player.RiotID(), result.Downloaded, result.Failed)
+	if err != nil {
+		msg = fmt.Sprintf("Historique de %s interrompu (%d games stockées), relancer la commande pour reprendre: %s", player.RiotID(), result.Downloaded, err.Error())
+	}
+	if _, err := discord.ChannelMessageEdit(channelID, status.ID, msg); err != nil {
+		log.Println("couldn't edit backfill progress: " + err.Error())
+	}
+}
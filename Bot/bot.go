@@ -12,8 +12,11 @@ import (
 )
 
 var (
-	BotToken string
-	Bot      *discordgo.Session
+	BotToken  string
+	ChannelID string = "1273632829753917515"
+	Bot       *discordgo.Session
+	// Registered when the bot starts listening
+	slashCommands = []*discordgo.ApplicationCommand{settingsCommand, optoutCommand, privacyCommand, linkCommand}
 )
 
 func Init() (err error) {
@@ -30,6 +33,7 @@ func Listen() error {
 
 	// add a event handler
 	discord.AddHandler(newMessage)
+	discord.AddHandler(interactionCreate)
 
 	// open session
 	err := discord.Open()
@@ -39,6 +43,11 @@ func Listen() error {
 	}
 	defer discord.Close() // close session, after function termination
 
+	_, err = discord.ApplicationCommandBulkOverwrite(discord.State.User.ID, "", slashCommands)
+	if err != nil {
+		log.Println("couldn't register slash commands: " + err.Error())
+	}
+
 	// keep bot running untill there is NO os interruption (ctrl + C)
 	fmt.Println("Bot running....")
 	c := make(chan os.Signal, 1)
@@ -47,24 +56,25 @@ func Listen() error {
 	return nil
 }
 
+// Messages are sent through the REST API, they don't need the websocket opened
+// by Listen. No lock since the session is safe for concurrent use.
 func SendMessage(msg string) error {
-	err := Bot.Open()
+	_, err := Bot.ChannelMessageSend(ChannelID, msg)
 	if err != nil {
-		log.Fatal("Error opening discord websocket")
-		return err
+		log.Println("couldn't send message in channel")
 	}
-	defer Bot.Close() // close session, after function termination
-	_, err = Bot.ChannelMessageSend("1273632829753917515", msg)
-	if err != nil {
-		log.Fatal("couldn't send message in channel")
-	}
-	return nil
+	return err
 }
 
 func newMessage(discord *discordgo.Session, message *discordgo.MessageCreate) {
 	if message.Author.ID == discord.State.User.ID {
 		return
 	}
+	command, _, _ := strings.Cut(message.Content, " ")
+	if denial := checkMessagePermission(discord, command, message); denial != "" {
+		sendReply(discord, message.ChannelID, denial)
+		return
+	}
 
 	// respond to user message if it contains `!help` or `!bye`
 	switch {
@@ -82,5 +92,76 @@ func newMessage(discord *discordgo.Session, message *discordgo.MessageCreate) {
 		if err != nil {
 			log.Fatal("couldn't send message in channel")
 		}
+	case strings.HasPrefix(message.Content, "!backfill "):
+		go backfill(discord, message.ChannelID, strings.TrimPrefix(message.Content, "!backfill "))
+	case message.Content == "!leaderboard" || strings.HasPrefix(message.Content, "!leaderboard "):
+		go leaderboard(discord, message.ChannelID, strings.TrimPrefix(message.Content, "!leaderboard"))
+	case strings.HasPrefix(message.Content, "!mastery "):
+		go mastery(discord, message.ChannelID, strings.TrimPrefix(message.Content, "!mastery "))
+	case strings.HasPrefix(message.Content, "!scoreboard "):
+		go scoreboard(discord, message.ChannelID, strings.TrimPrefix(message.Content, "!scoreboard "))
+	case strings.HasPrefix(message.Content, "!champs "):
+		go champs(discord, message.ChannelID, strings.TrimPrefix(message.Content, "!champs "))
+	}
+}
+
+func interactionCreate(discord *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type == discordgo.InteractionMessageComponent {
+		if strings.HasPrefix(i.MessageComponentData().CustomID, reportPrefix+":") {
+			go reportButton(discord, i)
+		}
+		return
+	}
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	command := i.ApplicationCommandData().Name
+	if denial := checkInteractionPermission(command, i); denial != "" {
+		respond(discord, i, denial)
+		return
+	}
+	switch command {
+	case "settings":
+		go settings(discord, i)
+	case "optout":
+		optout(discord, i)
+	case "privacy":
+		go privacy(discord, i)
+	case "link":
+		go link(discord, i)
+	}
+}
+
+// Replies only visible to the user who ran the slash command
+func respond(discord *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	err := discord.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: msg, Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		log.Println("couldn't respond to interaction: " + err.Error())
+	}
+}
+
+// Acknowledges the slash command, the reply comes later with editResponse
+func deferResponse(discord *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := discord.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	if err != nil {
+		log.Println("couldn't respond to interaction: " + err.Error())
+	}
+}
+
+func editResponse(discord *discordgo.Session, i *discordgo.InteractionCreate, msg string) {
+	if _, err := discord.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg}); err != nil {
+		log.Println("couldn't edit interaction response: " + err.Error())
+	}
+}
+
+func sendReply(discord *discordgo.Session, channelID string, msg string) {
+	if _, err := discord.ChannelMessageSend(channelID, msg); err != nil {
+		log.Println("couldn't send message in channel: " + err.Error())
 	}
 }
@@ -0,0 +1,87 @@
+package bot
+
+/* Command showing a player's stats on each champion */
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	Api "github.com/Nvim/silverstalker/Api"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Splits "riot#id key=value key=value" into the Riot ID and its options.
+// Riot IDs may contain spaces, so everything before the first option is kept.
+func parseArgs(args string) (string, map[string]string) {
+	options := make(map[string]string)
+	words := strings.Fields(args)
+	riotID := make([]string, 0, len(words))
+	for _, word := range words {
+		if key, value, found := strings.Cut(word, "="); found {
+			options[strings.ToLower(key)] = value
+			continue
+		}
+		riotID = append(riotID, word)
+	}
+	return strings.Join(riotID, " "), options
+}
+
+// Builds a filter from the queue=, since= and until= options (dates as YYYY-MM-DD)
+func parseFilter(options map[string]string) (Api.StatsFilter, error) {
+	var filter Api.StatsFilter
+	var err error
+	if queue, ok := options["queue"]; ok {
+		if filter.Queue, err = strconv.Atoi(queue); err != nil {
+			return filter, errors.New("file invalide: " + queue)
+		}
+	}
+	if since, ok := options["since"]; ok {
+		if filter.From, err = time.Parse(time.DateOnly, since); err != nil {
+			return filter, errors.New("date invalide: " + since)
+		}
+	}
+	if until, ok := options["until"]; ok {
+		if filter.To, err = Api.ParseUntil(until); err != nil {
+			return filter, errors.New("date invalide: " + until)
+		}
+	}
+	return filter, nil
+}
+
+// !champs <riot#id> [sort=games|winrate|kda|cs|damage|score] [queue=420] [since=2024-01-01] [until=2024-12-31]
+func champs(discord *discordgo.Session, channelID string, args string) {
+	riotID, options := parseArgs(args)
+	msg, err := getChampsString(riotID, options)
+	if err != nil {
+		msg = "Erreur: " + err.Error()
+	}
+	sendReply(discord, channelID, msg)
+}
+
+func getChampsString(riotID string, options map[string]string) (string, error) {
+	player, err := resolvePlayer(riotID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkConsent(player); err != nil {
+		return "", err
+	}
+	filter, err := parseFilter(options)
+	if err != nil {
+		return "", err
+	}
+	matches, err := Api.GetPlayerMatches(player.PUUID)
+	if err != nil {
+		return "", err
+	}
+
+	champions := Api.GetChampionStats(matches, player.PUUID, filter)
+	if sort, ok := options["sort"]; ok {
+		if err := Api.SortChampionStats(champions, sort); err != nil {
+			return "", err
+		}
+	}
+	return Api.GetChampionStatsString(player, champions), nil
+}
@@ -0,0 +1,127 @@
+package bot
+
+/* Buttons under match reports, each one shows an expanded view of the game
+ * only to the user who clicked it */
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	Api "github.com/Nvim/silverstalker/Api"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Custom IDs look like "report:<view>:<match ID>:<participant ID>", Discord
+// limits them to 100 characters so PUUIDs don't fit
+const reportPrefix = "report"
+
+var reportViews = []struct {
+	name  string
+	label string
+	emoji string
+}{
+	{"stats", "Stats complètes", "📊"},
+	{"timeline", "Timeline", "⏱️"},
+	{"opponent", "Comparer à l'adversaire", "⚔️"},
+	{"lobby", "Toute la game", "👥"},
+}
+
+func reportCustomID(view string, matchID string, participantID int) string {
+	return strings.Join([]string{reportPrefix, view, matchID, strconv.Itoa(participantID)}, ":")
+}
+
+// Buttons of the report about the players: one row per player for group
+// reports, the player's name is then added to the labels
+func ReportComponents(match *Api.Match, players []*Api.PlayerInfo) []discordgo.MessageComponent {
+	rows := make([]discordgo.MessageComponent, 0, len(players))
+	for _, info := range players {
+		if len(rows) == 5 {
+			// Discord allows at most 5 rows
+			break
+		}
+		var participant *Api.Participant
+		for i, p := range match.Info.Participants {
+			if p.Puuid == info.PUUID {
+				participant = &match.Info.Participants[i]
+			}
+		}
+		if participant == nil {
+			continue
+		}
+		buttons := make([]discordgo.MessageComponent, 0, len(reportViews))
+		for _, view := range reportViews {
+			label := view.label
+			if len(players) > 1 {
+				label += " · " + info.GameName
+			}
+			buttons = append(buttons, discordgo.Button{
+				Label:    label,
+				Style:    discordgo.SecondaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: view.emoji},
+				CustomID: reportCustomID(view.name, match.Metadata.MatchID, participant.ParticipantID),
+			})
+		}
+		rows = append(rows, discordgo.ActionsRow{Components: buttons})
+	}
+	return rows
+}
+
+func getReportView(customID string) (string, error) {
+	parts := strings.Split(customID, ":")
+	if len(parts) != 4 {
+		return "", errors.New("bouton inconnu: " + customID)
+	}
+	view, matchID := parts[1], parts[2]
+	participantID, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return "", err
+	}
+
+	match, err := Api.GetMatchInfo(matchID)
+	if err != nil {
+		return "", err
+	}
+	puuid := ""
+	won := false
+	for _, p := range match.Info.Participants {
+		if p.ParticipantID == participantID {
+			puuid, won = p.Puuid, p.Win
+		}
+	}
+	if !Api.Allows(puuid, Api.ReportAnnouncement, won) {
+		return "", errors.New("ce joueur ne souhaite plus que ses stats soient affichées")
+	}
+	if view == "opponent" || view == "lobby" {
+		if err := checkMatchConsent(match); err != nil {
+			return "", err
+		}
+	}
+
+	switch view {
+	case "stats":
+		return Api.GetFullStatsString(match, puuid)
+	case "timeline":
+		timeline, err := Api.GetMatchTimeline(matchID)
+		if err != nil {
+			return "", err
+		}
+		return Api.GetTimelineString(match, timeline, puuid)
+	case "opponent":
+		return Api.GetOpponentString(match, puuid)
+	case "lobby":
+		// Ranks would need requests to Riot
+		return Api.GetScoreboardString(Api.GetScoreboard(match, Api.TrackedPlayers(), false)), nil
+	}
+	return "", fmt.Errorf("vue inconnue: %s", view)
+}
+
+func reportButton(discord *discordgo.Session, i *discordgo.InteractionCreate) {
+	deferResponse(discord, i)
+	msg, err := getReportView(i.MessageComponentData().CustomID)
+	if err != nil {
+		msg = "Erreur: " + err.Error()
+	}
+	editResponse(discord, i, msg)
+}
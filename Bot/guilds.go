@@ -0,0 +1,146 @@
+package bot
+
+/* Settings of each Discord server the bot is in */
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	Api "github.com/Nvim/silverstalker/Api"
+	storage "github.com/Nvim/silverstalker/Storage"
+)
+
+type GuildSettings storage.GuildSettings
+
+var (
+	GuildsFile string = "guilds.json"                   // imported in the store if it has no guild yet
+	guilds            = make(map[string]*GuildSettings) // keyed by guild ID
+	guildsMu   sync.Mutex
+	// Called with the tracked players after a guild started tracking someone new,
+	// or stopped tracking the last player only guilds tracked
+	OnPlayersChanged = func(players []*Api.PlayerInfo) {}
+)
+
+func LoadGuilds() error {
+	guildsMu.Lock()
+	defer guildsMu.Unlock()
+
+	stored, err := storage.Store.Guilds()
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 {
+		return importGuilds()
+	}
+	loaded := make(map[string]*GuildSettings, len(stored))
+	for _, g := range stored {
+		settings := GuildSettings(g)
+		loaded[g.GuildID] = &settings
+	}
+	guilds = loaded
+	return nil
+}
+
+// Moves the settings of the guilds file to the store
+func importGuilds() error {
+	data, err := os.ReadFile(GuildsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]*GuildSettings)
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return Api.ErrJson
+	}
+	guilds = loaded
+	log.Printf("Importing %d guilds from %s\n", len(loaded), GuildsFile)
+	return saveGuilds()
+}
+
+func saveGuilds() error {
+	for _, g := range guilds {
+		if err := storage.Store.SaveGuild(storage.GuildSettings(*g)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *GuildSettings) Options() Api.ReportOptions {
+	return Api.ReportOptions{Template: g.Template, Roast: g.Roast, Locale: g.Locale, Scoreboard: g.Scoreboard, Card: g.Card}
+}
+
+// The guild's tracked players among the given ones
+func (g *GuildSettings) TrackedAmong(players []*Api.PlayerInfo) []*Api.PlayerInfo {
+	tracked := make([]*Api.PlayerInfo, 0)
+	for _, p := range players {
+		if slices.Contains(g.Players, p.PUUID) {
+			tracked = append(tracked, p)
+		}
+	}
+	return tracked
+}
+
+// Copy of the guild's settings, defaults if it has none yet
+func GetGuild(guildID string) GuildSettings {
+	guildsMu.Lock()
+	defer guildsMu.Unlock()
+	if g, ok := guilds[guildID]; ok {
+		settings := *g
+		settings.Players = slices.Clone(g.Players)
+		return settings
+	}
+	defaults := Api.DefaultReportOptions
+	return GuildSettings{GuildID: guildID, Template: defaults.Template, Roast: defaults.Roast, Locale: defaults.Locale, Scoreboard: defaults.Scoreboard, Card: defaults.Card}
+}
+
+// Applies update to a copy of the guild's settings and saves them if they're valid
+func updateGuild(guildID string, update func(*GuildSettings) error) (GuildSettings, error) {
+	settings := GetGuild(guildID)
+	if err := update(&settings); err != nil {
+		return settings, err
+	}
+	if err := settings.Options().Validate(); err != nil {
+		return settings, err
+	}
+
+	guildsMu.Lock()
+	defer guildsMu.Unlock()
+	guilds[guildID] = &settings
+	return settings, storage.Store.SaveGuild(storage.GuildSettings(settings))
+}
+
+// Whether a guild still tracks the player
+func trackedByGuilds(puuid string) bool {
+	guildsMu.Lock()
+	defer guildsMu.Unlock()
+	for _, g := range guilds {
+		if slices.Contains(g.Players, puuid) {
+			return true
+		}
+	}
+	return false
+}
+
+// Guilds with an announcement channel tracking at least one of the players
+func GuildsTracking(players []*Api.PlayerInfo) []GuildSettings {
+	guildsMu.Lock()
+	defer guildsMu.Unlock()
+	tracking := make([]GuildSettings, 0)
+	for _, g := range guilds {
+		if g.ChannelID != "" && len(g.TrackedAmong(players)) > 0 {
+			settings := *g
+			settings.Players = slices.Clone(g.Players)
+			tracking = append(tracking, settings)
+		}
+	}
+	slices.SortFunc(tracking, func(a, b GuildSettings) int { return strings.Compare(a.GuildID, b.GuildID) })
+	return tracking
+}
@@ -0,0 +1,115 @@
+package bot
+
+/* Leaderboard command and the pinned leaderboard kept up to date */
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	Api "github.com/Nvim/silverstalker/Api"
+	notify "github.com/Nvim/silverstalker/Notify"
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	LeaderboardFile string = "leaderboard.json"
+	PinnedStat      string = "rank"
+	pinnedMu        sync.Mutex
+)
+
+// !leaderboard [stat] [period]
+func leaderboard(discord *discordgo.Session, channelID string, args string) {
+	words := strings.Fields(args)
+	stat, period := "", ""
+	if len(words) > 0 {
+		stat = words[0]
+	}
+	if len(words) > 1 {
+		period = words[1]
+	}
+
+	filter, label, err := Api.ParsePeriod(period)
+	if err != nil {
+		sendReply(discord, channelID, "Erreur: "+err.Error())
+		return
+	}
+	entries, err := Api.GetLeaderboard(Api.AllowingPlayers(Api.TrackedPlayers(), Api.ReportAnnouncement, false), stat, filter)
+	if err != nil {
+		sendReply(discord, channelID, "Erreur: "+err.Error())
+		return
+	}
+	sendReply(discord, channelID, Api.GetLeaderboardString(stat, label, entries))
+}
+
+// Pinned message IDs, keyed by notifier name
+func loadPinned() (map[string]string, error) {
+	pinned := make(map[string]string)
+	data, err := os.ReadFile(LeaderboardFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return pinned, nil
+	}
+	if err != nil {
+		return pinned, err
+	}
+	if err := json.Unmarshal(data, &pinned); err != nil {
+		return pinned, Api.ErrJson
+	}
+	return pinned, nil
+}
+
+func savePinned(pinned map[string]string) error {
+	data, err := json.MarshalIndent(pinned, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(LeaderboardFile, data, 0o644)
+}
+
+// Edits the pinned leaderboard in place, posts and pins it the first time.
+// Notifiers that can't edit messages get none, rather than one per game.
+func UpdatePinnedLeaderboard(players []*Api.PlayerInfo, notifier notify.Notifier) error {
+	if !notify.CanEdit(notifier) {
+		return nil
+	}
+	pinnedMu.Lock()
+	defer pinnedMu.Unlock()
+
+	players = Api.AllowingPlayers(players, Api.ReportAnnouncement, false)
+	entries, err := Api.GetLeaderboard(players, PinnedStat, Api.StatsFilter{})
+	if err != nil {
+		return err
+	}
+	_, label, _ := Api.ParsePeriod("")
+	msg := Api.GetLeaderboardString(PinnedStat, label, entries)
+
+	pinned, err := loadPinned()
+	if err != nil {
+		return err
+	}
+	if id, ok := pinned[notifier.Name()]; ok {
+		err := notifier.Edit(id, msg)
+		if err == nil {
+			return nil
+		}
+		log.Println("couldn't edit pinned leaderboard, posting a new one: " + err.Error())
+	}
+
+	id, err := notifier.Send(msg, "")
+	if err != nil {
+		return err
+	}
+	if pinner, ok := notifier.(notify.Pinner); ok {
+		if err := pinner.Pin(id); err != nil {
+			log.Println("couldn't pin leaderboard: " + err.Error())
+		}
+	}
+	if id == "" {
+		return nil
+	}
+	pinned[notifier.Name()] = id
+	return savePinned(pinned)
+}
@@ -0,0 +1,139 @@
+package bot
+
+/* /link slash command: users prove they own a Riot account by setting a given
+ * profile icon, then get linked to it */
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	Api "github.com/Nvim/silverstalker/Api"
+	static "github.com/Nvim/silverstalker/Static"
+	"github.com/bwmarrin/discordgo"
+)
+
+type linkChallenge struct {
+	puuid   string
+	iconID  int
+	expires time.Time
+}
+
+const (
+	// Profile icons every account owns
+	starterIcons    = 29
+	challengeExpiry = 10 * time.Minute
+)
+
+var (
+	challenges   = make(map[string]linkChallenge) // keyed by Discord user ID
+	challengesMu sync.Mutex
+	linkCommand  = &discordgo.ApplicationCommand{
+		Name:        "link",
+		Description: "Lie ton compte Discord à ton compte Riot",
+		Options:     []*discordgo.ApplicationCommandOption{riotIDOption},
+	}
+)
+
+// Picks a starter icon the player isn't using
+func newLinkChallenge(player *Api.PlayerInfo) (linkChallenge, error) {
+	current, err := player.GetProfileIconID()
+	if err != nil {
+		return linkChallenge{}, err
+	}
+	icon := rand.IntN(starterIcons - 1)
+	if icon >= current {
+		icon++
+	}
+	return linkChallenge{player.PUUID, icon, time.Now().Add(challengeExpiry)}, nil
+}
+
+// Forgets expired challenges, challengesMu must be held
+func pruneChallenges() {
+	now := time.Now()
+	for userID, c := range challenges {
+		if now.After(c.expires) {
+			delete(challenges, userID)
+		}
+	}
+}
+
+func getIconURL(iconID int) string {
+	versions := static.Versions()
+	if len(versions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://ddragon.leagueoflegends.com/cdn/%s/img/profileicon/%d.png", versions[0], iconID)
+}
+
+// The first /link gives the icon to set, running it again once the icon is
+// set links the accounts
+func link(discord *discordgo.Session, i *discordgo.InteractionCreate) {
+	deferResponse(discord, i)
+	userID := interactionUserID(i)
+	riotID := i.ApplicationCommandData().Options[0].StringValue()
+	player, err := resolvePlayer(riotID)
+	if err != nil {
+		editResponse(discord, i, "Joueur introuvable: "+err.Error())
+		return
+	}
+
+	challengesMu.Lock()
+	challenge, pending := challenges[userID]
+	challengesMu.Unlock()
+	if !pending || challenge.puuid != player.PUUID || time.Now().After(challenge.expires) {
+		challenge, err = newLinkChallenge(player)
+		if err != nil {
+			editResponse(discord, i, "Erreur: "+err.Error())
+			return
+		}
+		challengesMu.Lock()
+		pruneChallenges()
+		challenges[userID] = challenge
+		challengesMu.Unlock()
+
+		msg := fmt.Sprintf("Pour prouver que %s est à toi, mets l'icône d'invocateur n°%d dans le client puis relance /link %s dans les %d minutes\n",
+			player.RiotID(), challenge.iconID, player.RiotID(), int(challengeExpiry.Minutes()))
+		editResponse(discord, i, msg+getIconURL(challenge.iconID))
+		return
+	}
+
+	icon, err := player.GetProfileIconID()
+	if err != nil {
+		editResponse(discord, i, "Erreur: "+err.Error())
+		return
+	}
+	if icon != challenge.iconID {
+		editResponse(discord, i, fmt.Sprintf("L'icône de %s est toujours la n°%d au lieu de la n°%d, le changement peut prendre une minute", player.RiotID(), icon, challenge.iconID))
+		return
+	}
+
+	challengesMu.Lock()
+	delete(challenges, userID)
+	challengesMu.Unlock()
+	if err := linkPlayer(userID, player); err != nil {
+		editResponse(discord, i, "Erreur: "+err.Error())
+		return
+	}
+	msg := "Ton compte est lié à " + player.RiotID() + ", tu peux remettre ton icône"
+	if Api.FindPlayer(player.RiotID()) == nil {
+		msg += "\n" + player.RiotID() + " n'est pas encore suivi: demande à un admin de lancer /settings track"
+	}
+	editResponse(discord, i, msg)
+}
+
+// Mentions of the Discord users linked to the players
+func Mentions(players []*Api.PlayerInfo) string {
+	linksMu.Lock()
+	defer linksMu.Unlock()
+	s := ""
+	for userID, puuid := range links {
+		for _, p := range players {
+			if p.PUUID == puuid {
+				s += "<@" + userID + "> "
+			}
+		}
+	}
+	return s
+}
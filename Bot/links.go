@@ -0,0 +1,126 @@
+package bot
+
+/* Discord accounts linked to Riot accounts, tracked or not */
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"slices"
+	"sync"
+
+	Api "github.com/Nvim/silverstalker/Api"
+	storage "github.com/Nvim/silverstalker/Storage"
+)
+
+var (
+	LinksFile string = "links.json"            // imported in the store if it has no link yet
+	links            = make(map[string]string) // Discord user ID -> PUUID
+	linksMu   sync.Mutex
+)
+
+// Links are the stored accounts having a player, whose ID is the Discord user's
+func LoadLinks() error {
+	linksMu.Lock()
+	defer linksMu.Unlock()
+
+	accounts, err := storage.Store.Accounts()
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]string)
+	for _, a := range accounts {
+		if a.PlayerID != "" {
+			loaded[a.PlayerID] = a.PUUID
+		}
+	}
+	links = loaded
+	if len(links) == 0 {
+		return importLinks()
+	}
+	return nil
+}
+
+// Moves the links of the links file to the store
+func importLinks() error {
+	data, err := os.ReadFile(LinksFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]string)
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return Api.ErrJson
+	}
+	log.Printf("Importing %d links from %s\n", len(loaded), LinksFile)
+	tracked := Api.TrackedPlayers()
+	for userID, puuid := range loaded {
+		// Only the PUUID of untracked accounts is known
+		player := &Api.PlayerInfo{PUUID: puuid}
+		if idx := slices.IndexFunc(tracked, func(p *Api.PlayerInfo) bool { return p.PUUID == puuid }); idx != -1 {
+			player = tracked[idx]
+		}
+		if err := storeLink(userID, player); err != nil {
+			return err
+		}
+		setLink(userID, puuid)
+	}
+	return nil
+}
+
+// Accounts of untracked players are stored untracked, stored accounts keep
+// their details
+func storeLink(userID string, player *Api.PlayerInfo) error {
+	if err := storage.Store.SavePlayer(storage.Player{ID: userID}); err != nil {
+		return err
+	}
+	account := storage.AccountOf(player)
+	account.PlayerID = userID
+	return storage.Store.LinkAccount(account)
+}
+
+// Links the Discord user to the player, replacing their previous link
+func linkPlayer(userID string, player *Api.PlayerInfo) error {
+	linksMu.Lock()
+	defer linksMu.Unlock()
+	if previous := links[userID]; previous != "" && previous != player.PUUID {
+		if err := storage.Store.UnlinkAccount(previous); err != nil {
+			return err
+		}
+	}
+	if err := storeLink(userID, player); err != nil {
+		return err
+	}
+	setLink(userID, player.PUUID)
+	return nil
+}
+
+// An account has a single player: the users it was linked to lose it.
+// linksMu must be held.
+func setLink(userID string, puuid string) {
+	for other, linked := range links {
+		if linked == puuid {
+			delete(links, other)
+		}
+	}
+	links[userID] = puuid
+}
+
+// Tracked player linked to the Discord user, nil if there is none
+func LinkedPlayer(userID string) *Api.PlayerInfo {
+	linksMu.Lock()
+	puuid, ok := links[userID]
+	linksMu.Unlock()
+	if !ok {
+		return nil
+	}
+	for _, p := range Api.TrackedPlayers() {
+		if p.PUUID == puuid {
+			return p
+		}
+	}
+	return nil
+}
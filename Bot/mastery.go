@@ -0,0 +1,26 @@
+package bot
+
+/* Command showing a player's best champion masteries */
+
+import (
+	Api "github.com/Nvim/silverstalker/Api"
+	"github.com/bwmarrin/discordgo"
+)
+
+// !mastery <riot#id>
+func mastery(discord *discordgo.Session, channelID string, riotID string) {
+	player, err := resolvePlayer(riotID)
+	if err != nil {
+		sendReply(discord, channelID, "Joueur introuvable: "+err.Error())
+		return
+	}
+	if err := checkConsent(player); err != nil {
+		sendReply(discord, channelID, err.Error())
+		return
+	}
+	msg, err := Api.GetMasteryString(player, 10)
+	if err != nil {
+		msg = "Erreur: " + err.Error()
+	}
+	sendReply(discord, channelID, msg)
+}
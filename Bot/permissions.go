@@ -0,0 +1,82 @@
+package bot
+
+/* Who may run which command */
+
+import (
+	"log"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type Permission int
+
+const (
+	Everyone Permission = iota
+	Player              // users linked to a tracked player, acting on themselves
+	Admin               // Manage Server permission or the guild's admin role
+)
+
+// Commands missing from the map are open to everyone
+var commandPermissions = map[string]Permission{
+	"!backfill": Admin,
+	"settings":  Admin,
+	"optout":    Player,
+	"privacy":   Player,
+}
+
+var denials = map[Permission]string{
+	Player: "⛔ Cette commande est réservée aux joueurs suivis: lie d'abord ton compte avec /link",
+	Admin:  "⛔ Cette commande est réservée aux admins du serveur",
+}
+
+// Whether a member with the given channel permissions and roles administers the guild
+func isAdmin(guildID string, permissions int64, roles []string) bool {
+	if guildID == "" {
+		return false
+	}
+	if permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageServer) != 0 {
+		return true
+	}
+	adminRole := GetGuild(guildID).AdminRole
+	return adminRole != "" && slices.Contains(roles, adminRole)
+}
+
+// Returns the denial message if the user can't run the command, empty otherwise
+func checkPermission(command string, guildID string, userID string, permissions int64, roles []string) string {
+	needed := commandPermissions[command]
+	switch {
+	case needed == Everyone:
+		return ""
+	case isAdmin(guildID, permissions, roles):
+		return ""
+	case needed == Player && LinkedPlayer(userID) != nil:
+		return ""
+	}
+	return denials[needed]
+}
+
+func checkMessagePermission(discord *discordgo.Session, command string, message *discordgo.MessageCreate) string {
+	if commandPermissions[command] == Everyone {
+		return ""
+	}
+	var permissions int64
+	var roles []string
+	if message.GuildID != "" && message.Member != nil {
+		var err error
+		permissions, err = discord.State.MessagePermissions(message.Message)
+		if err != nil {
+			log.Println("couldn't get permissions of " + message.Author.ID + ": " + err.Error())
+		}
+		roles = message.Member.Roles
+	}
+	return checkPermission(command, message.GuildID, message.Author.ID, permissions, roles)
+}
+
+func checkInteractionPermission(command string, i *discordgo.InteractionCreate) string {
+	if i.Member == nil {
+		// Direct message
+		return checkPermission(command, "", i.User.ID, 0, nil)
+	}
+	return checkPermission(command, i.GuildID, i.Member.User.ID, i.Member.Permissions, i.Member.Roles)
+}
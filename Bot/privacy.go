@@ -0,0 +1,150 @@
+package bot
+
+/* /privacy slash command: linked players choose what is announced about them
+ * and can have their data deleted */
+
+import (
+	"errors"
+	"slices"
+
+	Api "github.com/Nvim/silverstalker/Api"
+	storage "github.com/Nvim/silverstalker/Storage"
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	consentNames = map[string]string{
+		Api.ConsentAll:       "tout (live, rapports, roasts et alertes)",
+		Api.ConsentWins:      "seulement les victoires",
+		Api.ConsentSummaries: "seulement les rapports, sans roast",
+		Api.ConsentNone:      "rien",
+	}
+	privacyCommand = &discordgo.ApplicationCommand{
+		Name:        "privacy",
+		Description: "Ce que le bot annonce sur toi et tes données",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Affiche ce que le bot annonce sur toi",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "announcements",
+				Description: "Choisis ce que le bot annonce sur toi",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "annonces",
+					Description: "Annonces autorisées",
+					Choices:     consentChoices(),
+					Required:    true,
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "delete",
+				Description: "Arrête de te suivre et supprime tes games et snapshots",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "confirmer",
+					Description: "La suppression est définitive",
+					Required:    true,
+				}},
+			},
+		},
+	}
+)
+
+// Refuses showing the stats of players who didn't agree to reports about them
+func checkConsent(player *Api.PlayerInfo) error {
+	if !Api.Allows(player.PUUID, Api.ReportAnnouncement, false) {
+		return errors.New(player.RiotID() + " ne souhaite pas que ses stats soient affichées")
+	}
+	return nil
+}
+
+// Refuses showing a whole game when one of its tracked players didn't agree
+func checkMatchConsent(match *Api.Match) error {
+	if !Api.AllAllowInMatch(match, Api.TrackedPlayers(), Api.ReportAnnouncement) {
+		return errors.New("un joueur de cette game ne souhaite pas que ses stats soient affichées")
+	}
+	return nil
+}
+
+func consentChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(Api.Consents))
+	for _, c := range Api.Consents {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: consentNames[c], Value: c})
+	}
+	return choices
+}
+
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil {
+		return i.Member.User.ID
+	}
+	return i.User.ID
+}
+
+func privacy(discord *discordgo.Session, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	player := LinkedPlayer(userID)
+	if player == nil {
+		respond(discord, i, denials[Player])
+		return
+	}
+	deferResponse(discord, i)
+
+	sub := i.ApplicationCommandData().Options[0]
+	var msg string
+	var err error
+	switch sub.Name {
+	case "show":
+		msg = "Annonces sur " + player.RiotID() + ": " + consentNames[Api.GetConsent(player.PUUID)]
+	case "announcements":
+		consent := sub.Options[0].StringValue()
+		err = Api.SetConsent(player.PUUID, consent)
+		msg = "Annonces sur " + player.RiotID() + ": " + consentNames[consent]
+	case "delete":
+		if !sub.Options[0].BoolValue() {
+			msg = "Suppression annulée"
+			break
+		}
+		err = deletePlayer(userID, player)
+		msg = player.RiotID() + " n'est plus suivi et ses données ont été supprimées"
+	}
+	if err != nil {
+		msg = "Erreur: " + err.Error()
+	}
+	editResponse(discord, i, msg)
+}
+
+// Untracks the player everywhere, deletes their data and unlinks the user
+func deletePlayer(userID string, player *Api.PlayerInfo) error {
+	if _, err := Api.RemovePlayer(player.RiotID()); err != nil {
+		return err
+	}
+	OnPlayersChanged(Api.TrackedPlayers())
+
+	guildsMu.Lock()
+	for _, g := range guilds {
+		g.Players = slices.DeleteFunc(g.Players, func(puuid string) bool { return puuid == player.PUUID })
+	}
+	err := saveGuilds()
+	guildsMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := Api.DeletePlayerData(player); err != nil {
+		return err
+	}
+	if err := storage.Store.DeleteAccount(player.PUUID); err != nil {
+		return err
+	}
+
+	linksMu.Lock()
+	defer linksMu.Unlock()
+	delete(links, userID)
+	return storage.Store.DeletePlayer(userID)
+}
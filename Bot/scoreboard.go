@@ -0,0 +1,46 @@
+package bot
+
+/* Command showing the scoreboard of the 10 participants of a game */
+
+import (
+	"strings"
+
+	Api "github.com/Nvim/silverstalker/Api"
+	"github.com/bwmarrin/discordgo"
+)
+
+// !scoreboard <match-id|riot#id>, a Riot ID shows their latest ranked game
+func scoreboard(discord *discordgo.Session, channelID string, arg string) {
+	arg = strings.TrimSpace(arg)
+	matchID := arg
+	highlighted := Api.TrackedPlayers()
+	if strings.Contains(arg, "#") {
+		player, err := resolvePlayer(arg)
+		if err != nil {
+			sendReply(discord, channelID, "Joueur introuvable: "+err.Error())
+			return
+		}
+		if err := checkConsent(player); err != nil {
+			sendReply(discord, channelID, err.Error())
+			return
+		}
+		matchIDs, err := player.GetLatestMatches()
+		if err != nil || len(matchIDs) == 0 {
+			sendReply(discord, channelID, "Aucune game classée récente pour "+player.RiotID())
+			return
+		}
+		matchID = matchIDs[0]
+		highlighted = append([]*Api.PlayerInfo{player}, Api.TrackedPlayers()...)
+	}
+
+	match, err := Api.GetMatchInfo(matchID)
+	if err != nil {
+		sendReply(discord, channelID, "Game introuvable: "+err.Error())
+		return
+	}
+	if err := checkMatchConsent(match); err != nil {
+		sendReply(discord, channelID, err.Error())
+		return
+	}
+	sendReply(discord, channelID, Api.GetScoreboardString(Api.GetScoreboard(match, highlighted, true)))
+}
@@ -0,0 +1,346 @@
+package bot
+
+/* /settings slash command, configures the guild's tracked players and reports,
+ * and /optout for players who don't want to be tracked anymore */
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	Api "github.com/Nvim/silverstalker/Api"
+	static "github.com/Nvim/silverstalker/Static"
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	minRoast = 0.0
+	// Visible to everyone so that the guild's AdminRole can run it,
+	// commandPermissions restricts it
+	settingsCommand = &discordgo.ApplicationCommand{
+		Name:        "settings",
+		Description: "Réglages du bot sur ce serveur",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "show",
+				Description: "Affiche les réglages du serveur",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "channel",
+				Description: "Salon où les games sont annoncées",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "salon",
+					Description:  "Salon des annonces",
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					Required:     true,
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "track",
+				Description: "Suit les games d'un joueur",
+				Options:     []*discordgo.ApplicationCommandOption{riotIDOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "untrack",
+				Description: "Arrête de suivre un joueur",
+				Options:     []*discordgo.ApplicationCommandOption{riotIDOption},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "template",
+				Description: "Format des rapports de game",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "format",
+					Description: "full: tout, stats: sans le build, short: résultat seulement",
+					Choices:     templateChoices(),
+					Required:    true,
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "roast",
+				Description: "Intensité du roast dans les rapports",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "niveau",
+					Description: fmt.Sprintf("De 0 (aucun) à %d (sans pitié)", Api.MaxRoast),
+					MinValue:    &minRoast,
+					MaxValue:    Api.MaxRoast,
+					Required:    true,
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "locale",
+				Description: "Langue des noms de champions, objets et runes",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "locale",
+					Description: "Locale Data Dragon, par exemple fr_FR ou en_US",
+					Required:    true,
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "scoreboard",
+				Description: "Ajoute le tableau des 10 joueurs sous les rapports",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "actif",
+					Description: "Poster le tableau après chaque game",
+					Required:    true,
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "card",
+				Description: "Joint une image récapitulative de la game aux rapports",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "actif",
+					Description: "Joindre une carte par joueur suivi",
+					Required:    true,
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "admin-role",
+				Description: "Rôle autorisé à utiliser les commandes d'admin",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "role",
+					Description: "Rôle des admins du bot",
+					Required:    true,
+				}},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "link",
+				Description: "Lie un compte Discord à un joueur suivi",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "utilisateur",
+						Description: "Compte Discord du joueur",
+						Required:    true,
+					},
+					riotIDOption,
+				},
+			},
+		},
+	}
+	optoutCommand = &discordgo.ApplicationCommand{
+		Name:        "optout",
+		Description: "Arrête de suivre tes games sur ce serveur",
+	}
+	riotIDOption = &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "riot-id",
+		Description: "Riot ID du joueur, par exemple Pseudo#EUW",
+		Required:    true,
+	}
+)
+
+func templateChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(Api.Templates))
+	for _, t := range Api.Templates {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: t, Value: t})
+	}
+	return choices
+}
+
+func settings(discord *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respond(discord, i, "Cette commande n'est disponible que sur un serveur")
+		return
+	}
+	deferResponse(discord, i)
+
+	sub := i.ApplicationCommandData().Options[0]
+	var msg string
+	var err error
+	switch sub.Name {
+	case "show":
+		msg = getSettingsString(GetGuild(i.GuildID))
+	case "channel":
+		channelID := sub.Options[0].Value.(string)
+		_, err = updateGuild(i.GuildID, func(g *GuildSettings) error {
+			g.ChannelID = channelID
+			return nil
+		})
+		msg = "Les games seront annoncées dans <#" + channelID + ">"
+	case "track":
+		msg, err = trackPlayer(i.GuildID, sub.Options[0].StringValue())
+	case "untrack":
+		msg, err = untrackPlayer(i.GuildID, sub.Options[0].StringValue())
+	case "template":
+		template := sub.Options[0].StringValue()
+		_, err = updateGuild(i.GuildID, func(g *GuildSettings) error {
+			g.Template = template
+			return nil
+		})
+		msg = "Format des rapports: " + template
+	case "roast":
+		roast := int(sub.Options[0].IntValue())
+		_, err = updateGuild(i.GuildID, func(g *GuildSettings) error {
+			g.Roast = roast
+			return nil
+		})
+		msg = fmt.Sprintf("Niveau de roast: %d", roast)
+	case "locale":
+		locale := sub.Options[0].StringValue()
+		_, err = updateGuild(i.GuildID, func(g *GuildSettings) error {
+			if locales := static.Locales(); !slices.Contains(locales, locale) {
+				return errors.New("locale inconnue: " + locale + " (" + strings.Join(locales, ", ") + ")")
+			}
+			g.Locale = locale
+			return nil
+		})
+		msg = "Locale: " + locale
+	case "scoreboard":
+		enabled := sub.Options[0].BoolValue()
+		_, err = updateGuild(i.GuildID, func(g *GuildSettings) error {
+			g.Scoreboard = enabled
+			return nil
+		})
+		msg = "Tableau des joueurs sous les rapports: " + onOff(enabled)
+	case "card":
+		enabled := sub.Options[0].BoolValue()
+		_, err = updateGuild(i.GuildID, func(g *GuildSettings) error {
+			g.Card = enabled
+			return nil
+		})
+		msg = "Image récapitulative dans les rapports: " + onOff(enabled)
+	case "admin-role":
+		roleID := sub.Options[0].Value.(string)
+		_, err = updateGuild(i.GuildID, func(g *GuildSettings) error {
+			g.AdminRole = roleID
+			return nil
+		})
+		msg = "Les membres de <@&" + roleID + "> peuvent maintenant configurer le bot"
+	case "link":
+		userID := sub.Options[0].Value.(string)
+		riotID := sub.Options[1].StringValue()
+		player := Api.FindPlayer(riotID)
+		if player == nil {
+			err = errors.New(riotID + " n'est pas suivi")
+			break
+		}
+		err = linkPlayer(userID, player)
+		msg = "<@" + userID + "> est lié à " + player.RiotID()
+	}
+	if err != nil {
+		msg = "Erreur: " + err.Error()
+	}
+	editResponse(discord, i, msg)
+}
+
+// Starts tracking the player globally if nobody did yet
+func trackPlayer(guildID string, riotID string) (string, error) {
+	player := Api.FindPlayer(riotID)
+	if player == nil {
+		var err error
+		player, err = Api.AddPlayer(riotID, guildID)
+		if err != nil {
+			return "", err
+		}
+		OnPlayersChanged(Api.TrackedPlayers())
+	}
+	_, err := updateGuild(guildID, func(g *GuildSettings) error {
+		if slices.Contains(g.Players, player.PUUID) {
+			return errors.New(player.RiotID() + " est déjà suivi sur ce serveur")
+		}
+		g.Players = append(g.Players, player.PUUID)
+		return nil
+	})
+	return player.RiotID() + " est maintenant suivi sur ce serveur", err
+}
+
+// The player stays tracked globally while other guilds or the main notifier
+// follow them
+func untrackPlayer(guildID string, riotID string) (string, error) {
+	player := Api.FindPlayer(riotID)
+	_, err := updateGuild(guildID, func(g *GuildSettings) error {
+		idx := -1
+		if player != nil {
+			idx = slices.Index(g.Players, player.PUUID)
+		}
+		if idx == -1 {
+			return errors.New(riotID + " n'est pas suivi sur ce serveur")
+		}
+		g.Players = slices.Delete(g.Players, idx, idx+1)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if player.Guild != "" && !trackedByGuilds(player.PUUID) {
+		if _, err := Api.RemovePlayer(player.RiotID()); err != nil {
+			return "", err
+		}
+		OnPlayersChanged(Api.TrackedPlayers())
+	}
+	return player.RiotID() + " n'est plus suivi sur ce serveur", nil
+}
+
+// Untracks the user's linked player in the guild
+func optout(discord *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == "" {
+		respond(discord, i, "Cette commande n'est disponible que sur un serveur")
+		return
+	}
+	player := LinkedPlayer(interactionUserID(i))
+	if player == nil {
+		respond(discord, i, denials[Player])
+		return
+	}
+	msg, err := untrackPlayer(i.GuildID, player.RiotID())
+	if err != nil {
+		msg = "Erreur: " + err.Error()
+	}
+	respond(discord, i, msg)
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "activé"
+	}
+	return "désactivé"
+}
+
+func getSettingsString(g GuildSettings) string {
+	s := "Réglages du serveur:\n"
+	if g.ChannelID == "" {
+		s += "- Salon des annonces: aucun\n"
+	} else {
+		s += "- Salon des annonces: <#" + g.ChannelID + ">\n"
+	}
+	names := make([]string, 0, len(g.Players))
+	for _, p := range g.TrackedAmong(Api.TrackedPlayers()) {
+		names = append(names, p.RiotID())
+	}
+	if len(names) == 0 {
+		names = append(names, "aucun")
+	}
+	s += "- Joueurs suivis: " + strings.Join(names, ", ") + "\n"
+	s += "- Format des rapports: " + g.Template + "\n"
+	s += fmt.Sprintf("- Niveau de roast: %d/%d\n", g.Roast, Api.MaxRoast)
+	locale := g.Locale
+	if locale == "" {
+		locale = static.Locale
+	}
+	s += "- Locale: " + locale + "\n"
+	s += "- Tableau des joueurs: " + onOff(g.Scoreboard) + "\n"
+	s += "- Image récapitulative: " + onOff(g.Card) + "\n"
+	if g.AdminRole != "" {
+		s += "- Rôle des admins: <@&" + g.AdminRole + ">\n"
+	}
+	return s
+}
@@ -0,0 +1,226 @@
+package card
+
+/* PNG summary card of a game: champion splash, result, KDA, key stats and
+ * worst stats, drawn with the Go fonts */
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // splash arts
+	"image/png"
+	"os"
+	"strings"
+	"sync"
+
+	api "github.com/Nvim/silverstalker/Api"
+	static "github.com/Nvim/silverstalker/Static"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	Width  = 900
+	Height = 360
+	margin = 40
+	column = 480 // x of the stats column
+)
+
+var (
+	winColor  = color.RGBA{0x3d, 0xa5, 0xff, 0xff}
+	lossColor = color.RGBA{0xe8, 0x40, 0x57, 0xff}
+	textColor = color.RGBA{0xf0, 0xf0, 0xf0, 0xff}
+	dimColor  = color.RGBA{0xa8, 0xa8, 0xb0, 0xff}
+	backColor = color.RGBA{0x14, 0x16, 0x1c, 0xff}
+
+	loadFonts = sync.OnceValues(func() (*opentype.Font, error) {
+		return opentype.Parse(goregular.TTF)
+	})
+	loadBoldFonts = sync.OnceValues(func() (*opentype.Font, error) {
+		return opentype.Parse(gobold.TTF)
+	})
+	faces    = make(map[string]font.Face)
+	renderMu sync.Mutex // faces can't be used concurrently
+)
+
+// Face of the Go font at the size, bold or regular
+func getFace(size float64, bold bool) (font.Face, error) {
+	key := fmt.Sprintf("%v/%v", size, bold)
+	if face, ok := faces[key]; ok {
+		return face, nil
+	}
+	parsed, err := loadFonts()
+	if bold {
+		parsed, err = loadBoldFonts()
+	}
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	faces[key] = face
+	return face, nil
+}
+
+type canvas struct {
+	img *image.RGBA
+	err error // first error, drawing stops after it
+}
+
+// Draws s with its baseline at y, shortened with an ellipsis past maxWidth
+func (c *canvas) text(x int, y int, size float64, bold bool, col color.Color, s string, maxWidth int) {
+	if c.err != nil {
+		return
+	}
+	face, err := getFace(size, bold)
+	if err != nil {
+		c.err = err
+		return
+	}
+	for maxWidth > 0 && font.MeasureString(face, s).Ceil() > maxWidth && len(s) > 1 {
+		runes := []rune(strings.TrimSuffix(s, "…"))
+		s = string(runes[:len(runes)-1]) + "…"
+	}
+	d := &font.Drawer{Dst: c.img, Src: image.NewUniform(col), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(s)
+}
+
+func (c *canvas) fill(r image.Rectangle, col color.Color) {
+	draw.Draw(c.img, r, image.NewUniform(col), image.Point{}, draw.Over)
+}
+
+// Splash art scaled to cover the card, darker on the left where the text is
+func (c *canvas) background(match *api.Match, championID int) {
+	c.fill(c.img.Bounds(), backColor)
+	if path := static.ChampionSplash(match.Info.GameVersion, championID); path != "" {
+		if splash, err := loadImage(path); err == nil {
+			draw.ApproxBiLinear.Scale(c.img, c.img.Bounds(), splash, coverRect(splash.Bounds(), Width, Height), draw.Over, nil)
+		}
+	}
+	for x := 0; x < Width; x++ {
+		// From 92% opaque on the left to 55% on the right
+		alpha := uint8(235 - 95*x/Width)
+		c.fill(image.Rect(x, 0, x+1, Height), color.RGBA{0x0a, 0x0b, 0x10, alpha})
+	}
+}
+
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
+
+// Centered part of src with the width/height ratio of the card
+func coverRect(src image.Rectangle, width int, height int) image.Rectangle {
+	w, h := src.Dx(), src.Dy()
+	if w*height > h*width {
+		cropped := h * width / height
+		x := src.Min.X + (w-cropped)/2
+		return image.Rect(x, src.Min.Y, x+cropped, src.Max.Y)
+	}
+	cropped := w * height / width
+	y := src.Min.Y + (h-cropped)/2
+	return image.Rect(src.Min.X, y, src.Max.X, y+cropped)
+}
+
+func formatThousands(v int) string {
+	if v < 1000 {
+		return fmt.Sprint(v)
+	}
+	return fmt.Sprintf("%.1fk", float64(v)/1000)
+}
+
+// Renders the card of the player's game. Worst stats are left out without roasting.
+func Render(match *api.Match, puuid string, opts api.ReportOptions) ([]byte, error) {
+	var player *api.Participant
+	for i, p := range match.Info.Participants {
+		if p.Puuid == puuid {
+			player = &match.Info.Participants[i]
+		}
+	}
+	if player == nil {
+		return nil, errors.New("couldn't find player's index")
+	}
+	renderMu.Lock()
+	defer renderMu.Unlock()
+
+	c := &canvas{img: image.NewRGBA(image.Rect(0, 0, Width, Height))}
+	c.background(match, player.ChampionID)
+
+	result, resultColor := "DÉFAITE", lossColor
+	if player.Win {
+		result, resultColor = "VICTOIRE", winColor
+	}
+	c.fill(image.Rect(0, 0, 10, Height), resultColor)
+
+	minutes := max(float64(match.Info.GameDuration)/60, 1)
+	lang := static.Lang(opts.Locale)
+	c.text(margin, 64, 36, true, resultColor, result, column-2*margin)
+	champion := player.ChampionName // without a bundle
+	if _, ok := static.ChampionKey(match.Info.GameVersion, player.ChampionID); ok {
+		champion = lang.ChampionName(match.Info.GameVersion, player.ChampionID)
+	}
+	c.text(margin, 94, 18, false, dimColor, fmt.Sprintf("%s · %d min", api.GetQueueName(match.Info.QueueID), int(minutes)), column-2*margin)
+	c.text(margin, 150, 30, true, textColor, champion, column-2*margin)
+	c.text(margin, 178, 18, false, dimColor, fmt.Sprintf("%s · %s", player.RiotIDGameName, player.TeamPosition), column-2*margin)
+	c.text(margin, 262, 44, true, textColor, fmt.Sprintf("%d / %d / %d", player.Kills, player.Deaths, player.Assists), column-2*margin)
+	c.text(margin, 292, 18, false, dimColor, fmt.Sprintf("KDA %.2f", player.Challenges.Kda), column-2*margin)
+
+	cs := player.TotalMinionsKilled + player.NeutralMinionsKilled
+	stats := [][2]string{
+		{"CS", fmt.Sprintf("%d (%.1f/min)", cs, float64(cs)/minutes)},
+		{"Gold", formatThousands(player.GoldEarned)},
+		{"Dégâts", formatThousands(player.TotalDamageDealtToChampions)},
+		{"Dégâts/min", fmt.Sprintf("%.0f", player.Challenges.DamagePerMinute)},
+		{"Vision", fmt.Sprint(player.VisionScore)},
+		{"Niveau", fmt.Sprint(player.ChampLevel)},
+	}
+	for i, stat := range stats {
+		x := column + (i%2)*200
+		y := 60 + (i/2)*62
+		c.text(x, y, 15, false, dimColor, strings.ToUpper(stat[0]), 190)
+		c.text(x, y+28, 24, true, textColor, stat[1], 190)
+	}
+
+	if opts.Roast > 0 {
+		c.drawWorstStats(match, puuid, opts.Roast)
+	}
+
+	if c.err != nil {
+		return nil, c.err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Worst stats of the player, only the worst one at roast 1
+func (c *canvas) drawWorstStats(match *api.Match, puuid string, roast int) {
+	worst, err := api.GetWorstStats(match, puuid)
+	if err != nil || len(worst) == 0 {
+		return
+	}
+	count := 3
+	if roast == 1 {
+		count = 1
+	}
+	c.text(column, 250, 15, false, dimColor, "PIRES STATS", Width-column-margin)
+	for i, stat := range worst[:min(count, len(worst))] {
+		line := fmt.Sprintf("▼ %s: %.1f (moy. %.1f)", stat.Label, stat.Player, stat.GameAvg)
+		c.text(column, 276+i*24, 17, false, lossColor, line, Width-column-margin)
+	}
+}
@@ -0,0 +1,252 @@
+package export
+
+/* Flattened tables of the stored games, for notebooks */
+
+import (
+	"cmp"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+
+	api "github.com/Nvim/silverstalker/Api"
+	storage "github.com/Nvim/silverstalker/Storage"
+)
+
+type Filter struct {
+	Players []*api.PlayerInfo // tracked players whose games are exported, see api.AllowingPlayers
+	api.StatsFilter
+}
+
+// One row per participant of each game, tracked or not. Tracked players who
+// didn't agree to reports about the game are left out.
+type ParticipantRow struct {
+	MatchID              string  `json:"matchId"`
+	GameCreation         int64   `json:"gameCreation"` // epoch milliseconds
+	GameDuration         int64   `json:"gameDuration"` // seconds
+	GameVersion          string  `json:"gameVersion"`
+	QueueID              int64   `json:"queueId"`
+	PUUID                string  `json:"puuid"`
+	RiotID               string  `json:"riotId"`
+	Tracked              bool    `json:"tracked"`
+	TeamID               int64   `json:"teamId"`
+	Win                  bool    `json:"win"`
+	Champion             string  `json:"champion"`
+	Position             string  `json:"position"`
+	ChampLevel           int64   `json:"champLevel"`
+	Kills                int64   `json:"kills"`
+	Deaths               int64   `json:"deaths"`
+	Assists              int64   `json:"assists"`
+	Kda                  float64 `json:"kda"`
+	SoloKills            int64   `json:"soloKills"`
+	CS                   int64   `json:"cs"`
+	CSFirst10Minutes     int64   `json:"csFirst10Minutes"`
+	GoldEarned           int64   `json:"goldEarned"`
+	GoldPerMinute        float64 `json:"goldPerMinute"`
+	Damage               int64   `json:"damage"`
+	DamagePerMinute      float64 `json:"damagePerMinute"`
+	TeamDamagePercentage float64 `json:"teamDamagePercentage"`
+	DamageToObjectives   int64   `json:"damageToObjectives"`
+	VisionScore          int64   `json:"visionScore"`
+	WardsPlaced          int64   `json:"wardsPlaced"`
+	ControlWardsBought   int64   `json:"controlWardsBought"`
+	TimeSpentDead        int64   `json:"timeSpentDead"` // seconds
+}
+
+// One row per computed stat of each tracked player's game
+type StatRow struct {
+	MatchID      string  `json:"matchId"`
+	GameCreation int64   `json:"gameCreation"`
+	QueueID      int64   `json:"queueId"`
+	PUUID        string  `json:"puuid"`
+	RiotID       string  `json:"riotId"`
+	Stat         string  `json:"stat"`
+	Player       float64 `json:"player"`
+	TeamAvg      float64 `json:"teamAvg"`
+	GameAvg      float64 `json:"gameAvg"`
+	IsTeamMin    bool    `json:"isTeamMin"`
+	IsGameMin    bool    `json:"isGameMin"`
+}
+
+// One row per rank snapshot of a tracked player
+type RankRow struct {
+	PUUID        string `json:"puuid"`
+	RiotID       string `json:"riotId"`
+	Time         int64  `json:"time"` // epoch seconds
+	Tier         string `json:"tier"`
+	Rank         string `json:"rank"`
+	LeaguePoints int64  `json:"leaguePoints"`
+	Wins         int64  `json:"wins"`
+	Losses       int64  `json:"losses"`
+	RankValue    int64  `json:"rankValue"` // see api.RankValue
+}
+
+var Tables = []string{"participants", "stats", "ranks"}
+
+// Writes the table in the format, both must be known
+func Export(w io.Writer, table string, format string, filter Filter) error {
+	if !slices.Contains(Formats, format) {
+		return errors.New("unknown format: " + format + " (" + strings.Join(Formats, ", ") + ")")
+	}
+	switch table {
+	case "participants":
+		rows, err := GetParticipantRows(filter)
+		if err != nil {
+			return err
+		}
+		return write(w, format, rows)
+	case "stats":
+		rows, err := GetStatRows(filter)
+		if err != nil {
+			return err
+		}
+		return write(w, format, rows)
+	case "ranks":
+		rows, err := GetRankRows(filter)
+		if err != nil {
+			return err
+		}
+		return write(w, format, rows)
+	}
+	return errors.New("unknown table: " + table + " (" + strings.Join(Tables, ", ") + ")")
+}
+
+// Stored games of the filter's players, oldest first and without duplicates
+func getMatches(filter Filter) ([]*api.Match, error) {
+	seen := make(map[string]bool)
+	matches := make([]*api.Match, 0)
+	for _, p := range filter.Players {
+		playerMatches, err := storage.Store.Matches(storage.MatchFilter{PUUID: p.PUUID, StatsFilter: filter.StatsFilter})
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range playerMatches {
+			if seen[match.Metadata.MatchID] {
+				continue
+			}
+			seen[match.Metadata.MatchID] = true
+			matches = append(matches, match)
+		}
+	}
+	slices.SortFunc(matches, func(a, b *api.Match) int {
+		return cmp.Compare(a.Info.GameCreation, b.Info.GameCreation)
+	})
+	return matches, nil
+}
+
+func GetParticipantRows(filter Filter) ([]ParticipantRow, error) {
+	matches, err := getMatches(filter)
+	if err != nil {
+		return nil, err
+	}
+	tracked := make(map[string]bool, len(api.TrackedPlayers()))
+	for _, p := range api.TrackedPlayers() {
+		tracked[p.PUUID] = true
+	}
+
+	rows := make([]ParticipantRow, 0, len(matches)*10)
+	for _, match := range matches {
+		for _, p := range match.Info.Participants {
+			if tracked[p.Puuid] && !api.Allows(p.Puuid, api.ReportAnnouncement, p.Win) {
+				continue
+			}
+			rows = append(rows, ParticipantRow{
+				MatchID:              match.Metadata.MatchID,
+				GameCreation:         match.Info.GameCreation,
+				GameDuration:         int64(match.Info.GameDuration),
+				GameVersion:          match.Info.GameVersion,
+				QueueID:              int64(match.Info.QueueID),
+				PUUID:                p.Puuid,
+				RiotID:               p.RiotIDGameName + "#" + p.RiotIDTagline,
+				Tracked:              tracked[p.Puuid],
+				TeamID:               int64(p.TeamId),
+				Win:                  p.Win,
+				Champion:             p.ChampionName,
+				Position:             p.TeamPosition,
+				ChampLevel:           int64(p.ChampLevel),
+				Kills:                int64(p.Kills),
+				Deaths:               int64(p.Deaths),
+				Assists:              int64(p.Assists),
+				Kda:                  p.Challenges.Kda,
+				SoloKills:            int64(p.Challenges.SoloKills),
+				CS:                   int64(p.TotalMinionsKilled + p.NeutralMinionsKilled),
+				CSFirst10Minutes:     int64(p.Challenges.LaneMinionsFirst10Minutes),
+				GoldEarned:           int64(p.GoldEarned),
+				GoldPerMinute:        p.Challenges.GoldPerMinute,
+				Damage:               int64(p.TotalDamageDealtToChampions),
+				DamagePerMinute:      p.Challenges.DamagePerMinute,
+				TeamDamagePercentage: p.Challenges.TeamDamagePercentage,
+				DamageToObjectives:   int64(p.DamageDealtToObjectives),
+				VisionScore:          int64(p.VisionScore),
+				WardsPlaced:          int64(p.WardsPlaced),
+				ControlWardsBought:   int64(p.VisionWardsBoughtInGame),
+				TimeSpentDead:        int64(p.TotalTimeSpentDead),
+			})
+		}
+	}
+	return rows, nil
+}
+
+// Games where stats can't be computed, such as arena, are left out
+func GetStatRows(filter Filter) ([]StatRow, error) {
+	matches, err := getMatches(filter)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]StatRow, 0)
+	for _, match := range matches {
+		for _, p := range api.GetTrackedInMatch(match, filter.Players) {
+			summaries, err := api.GetStatSummaries(match, p.PUUID)
+			if err != nil {
+				continue
+			}
+			for _, s := range summaries {
+				rows = append(rows, StatRow{
+					MatchID:      match.Metadata.MatchID,
+					GameCreation: match.Info.GameCreation,
+					QueueID:      int64(match.Info.QueueID),
+					PUUID:        p.PUUID,
+					RiotID:       p.RiotID(),
+					Stat:         s.Name,
+					Player:       s.Player,
+					TeamAvg:      s.TeamAvg,
+					GameAvg:      s.GameAvg,
+					IsTeamMin:    s.IsTeamMin,
+					IsGameMin:    s.IsGameMin,
+				})
+			}
+		}
+	}
+	return rows, nil
+}
+
+// Snapshots aren't tied to a queue, only the date range applies
+func GetRankRows(filter Filter) ([]RankRow, error) {
+	rows := make([]RankRow, 0)
+	for _, p := range filter.Players {
+		snapshots, err := storage.Store.LeagueSnapshots(p.PUUID)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range snapshots {
+			if !filter.From.IsZero() && s.Time < filter.From.Unix() {
+				continue
+			}
+			if !filter.To.IsZero() && s.Time >= filter.To.Unix() {
+				continue
+			}
+			rows = append(rows, RankRow{
+				PUUID:        p.PUUID,
+				RiotID:       p.RiotID(),
+				Time:         s.Time,
+				Tier:         s.Tier,
+				Rank:         s.Rank,
+				LeaguePoints: int64(s.LeaguePoints),
+				Wins:         int64(s.Wins),
+				Losses:       int64(s.Losses),
+				RankValue:    int64(s.RankValue()),
+			})
+		}
+	}
+	return rows, nil
+}
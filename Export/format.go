@@ -0,0 +1,84 @@
+package export
+
+/* Encoders of the row types, columns are named after the json tags */
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var Formats = []string{"csv", "jsonl", "parquet"}
+
+// Content types served by the HTTP API
+var ContentTypes = map[string]string{
+	"csv":     "text/csv; charset=utf-8",
+	"jsonl":   "application/jsonl",
+	"parquet": "application/vnd.apache.parquet",
+}
+
+func write[T any](w io.Writer, format string, rows []T) error {
+	switch format {
+	case "csv":
+		return writeCSV(w, rows)
+	case "jsonl":
+		return writeJSONL(w, rows)
+	case "parquet":
+		return writeParquet(w, rows)
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+func writeJSONL[T any](w io.Writer, rows []T) error {
+	// The encoder ends each value with a newline
+	encoder := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV[T any](w io.Writer, rows []T) error {
+	rowType := reflect.TypeFor[T]()
+	header := make([]string, rowType.NumField())
+	for i := range header {
+		header[i], _, _ = strings.Cut(rowType.Field(i).Tag.Get("json"), ",")
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(header))
+	for _, row := range rows {
+		v := reflect.ValueOf(row)
+		for i := range record {
+			record[i] = formatValue(v.Field(i))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	return fmt.Sprint(v.Interface())
+}
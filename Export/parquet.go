@@ -0,0 +1,279 @@
+package export
+
+/* Minimal Parquet writer: one row group, one uncompressed PLAIN page per
+column and only required flat columns, enough for the row types above.
+Metadata is serialized with the Thrift compact protocol, see
+https://github.com/apache/parquet-format */
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Parquet physical types
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeDouble    = 5
+	typeByteArray = 6
+)
+
+// Thrift compact protocol field types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+const (
+	parquetMagic       = "PAR1"
+	convertedUTF8      = 0
+	repetitionRequired = 0 // REQUIRED
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageData           = 0
+)
+
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // last field ID of each struct being written
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	t.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (t *thriftWriter) zigzag(v int64) {
+	t.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.zigzag(v)
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) string(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.binary(s)
+}
+
+func (t *thriftWriter) listHeader(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.varint(uint64(size))
+	}
+}
+
+// Writes a struct whose fields are written by body. id is 0 for list elements
+// and top level structs, which have no field header.
+func (t *thriftWriter) structure(id int16, body func()) {
+	if id != 0 {
+		t.fieldHeader(id, thriftStruct)
+	}
+	t.last = append(t.last, 0)
+	body()
+	t.last = t.last[:len(t.last)-1]
+	t.buf.WriteByte(0) // stop field
+}
+
+func (t *thriftWriter) root(body func()) []byte {
+	t.buf.Reset()
+	t.structure(0, body)
+	return t.buf.Bytes()
+}
+
+type parquetColumn struct {
+	name     string
+	typ      int32
+	data     bytes.Buffer
+	bits     byte // booleans are bit packed
+	nbits    int
+	offset   int64 // of the page header in the file
+	pageSize int64 // header included
+}
+
+func (c *parquetColumn) add(v reflect.Value) {
+	switch c.typ {
+	case typeBoolean:
+		if v.Bool() {
+			c.bits |= 1 << c.nbits
+		}
+		if c.nbits++; c.nbits == 8 {
+			c.flushBits()
+		}
+	case typeInt64:
+		c.data.Write(binary.LittleEndian.AppendUint64(nil, uint64(v.Int())))
+	case typeDouble:
+		c.data.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v.Float())))
+	case typeByteArray:
+		c.data.Write(binary.LittleEndian.AppendUint32(nil, uint32(v.Len())))
+		c.data.WriteString(v.String())
+	}
+}
+
+func (c *parquetColumn) flushBits() {
+	if c.nbits > 0 {
+		c.data.WriteByte(c.bits)
+		c.bits, c.nbits = 0, 0
+	}
+}
+
+func parquetType(kind reflect.Kind) (int32, error) {
+	switch kind {
+	case reflect.Bool:
+		return typeBoolean, nil
+	case reflect.Int64:
+		return typeInt64, nil
+	case reflect.Float64:
+		return typeDouble, nil
+	case reflect.String:
+		return typeByteArray, nil
+	}
+	return 0, fmt.Errorf("no parquet type for %s columns", kind)
+}
+
+// countingWriter keeps track of the offsets written in the file
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// Columns are the fields of T, named after their json tags. Rows are kept in
+// memory until the end since a column chunk is written at once.
+func writeParquet[T any](w io.Writer, rows []T) error {
+	rowType := reflect.TypeFor[T]()
+	columns := make([]*parquetColumn, rowType.NumField())
+	for i := range columns {
+		field := rowType.Field(i)
+		typ, err := parquetType(field.Type.Kind())
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		columns[i] = &parquetColumn{name: name, typ: typ}
+	}
+	for _, row := range rows {
+		v := reflect.ValueOf(row)
+		for i, c := range columns {
+			c.add(v.Field(i))
+		}
+	}
+
+	out := &countingWriter{w: w}
+	io.WriteString(out, parquetMagic)
+	var t thriftWriter
+	for _, c := range columns {
+		c.flushBits()
+		c.offset = out.n
+		header := t.root(func() {
+			t.i32(1, pageData)
+			t.i32(2, int32(c.data.Len()))
+			t.i32(3, int32(c.data.Len()))
+			t.structure(5, func() {
+				t.i32(1, int32(len(rows)))
+				t.i32(2, encodingPlain)
+				t.i32(3, encodingRLE)
+				t.i32(4, encodingRLE)
+			})
+		})
+		out.Write(header)
+		out.Write(c.data.Bytes())
+		c.pageSize = out.n - c.offset
+	}
+
+	var totalSize int64
+	for _, c := range columns {
+		totalSize += c.pageSize
+	}
+	footer := t.root(func() {
+		t.i32(1, 1) // format version
+		t.listHeader(2, thriftStruct, len(columns)+1)
+		t.structure(0, func() {
+			t.string(4, "schema")
+			t.i32(5, int32(len(columns)))
+		})
+		for _, c := range columns {
+			t.structure(0, func() {
+				t.i32(1, c.typ)
+				t.i32(3, repetitionRequired)
+				t.string(4, c.name)
+				if c.typ == typeByteArray {
+					t.i32(6, convertedUTF8)
+				}
+			})
+		}
+		t.i64(3, int64(len(rows)))
+		t.listHeader(4, thriftStruct, 1)
+		t.structure(0, func() {
+			t.listHeader(1, thriftStruct, len(columns))
+			for _, c := range columns {
+				t.structure(0, func() {
+					t.i64(2, c.offset)
+					t.structure(3, func() {
+						t.i32(1, c.typ)
+						t.listHeader(2, thriftI32, 2)
+						t.zigzag(encodingPlain)
+						t.zigzag(encodingRLE)
+						t.listHeader(3, thriftBinary, 1)
+						t.binary(c.name)
+						t.i32(4, codecUncompressed)
+						t.i64(5, int64(len(rows)))
+						t.i64(6, c.pageSize)
+						t.i64(7, c.pageSize)
+						t.i64(9, c.offset)
+					})
+				})
+			}
+			t.i64(2, totalSize)
+			t.i64(3, int64(len(rows)))
+		})
+		t.string(6, "silverstalker")
+	})
+	out.Write(footer)
+	out.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	io.WriteString(out, parquetMagic)
+	return out.err
+}
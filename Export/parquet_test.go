@@ -0,0 +1,192 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Reads Thrift compact protocol values: structs as maps keyed by field ID,
+// lists as slices, integers as int64 and binaries as strings
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) byte() byte {
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		panic("bad varint")
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case 3:
+		return int64(int8(r.byte()))
+	case 4, thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.byte()
+		size, elemType := int(header>>4), header&0x0f
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = r.value(elemType)
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	}
+	panic(fmt.Sprintf("unexpected thrift type %d", typ))
+}
+
+func (r *thriftReader) structure() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		typ := header & 0x0f
+		if delta := int16(header >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(r.zigzag())
+		}
+		fields[last] = r.value(typ)
+	}
+}
+
+type parquetTestRow struct {
+	Name  string  `json:"name"`
+	Count int64   `json:"count"`
+	Ratio float64 `json:"ratio"`
+	Won   bool    `json:"won,omitempty"`
+}
+
+// Decodes the columns written by writeParquet back into rows
+func readParquet(t *testing.T, file []byte) ([]string, []parquetTestRow) {
+	t.Helper()
+	if string(file[:4]) != parquetMagic || string(file[len(file)-4:]) != parquetMagic {
+		t.Fatal("missing PAR1 magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := (&thriftReader{data: file[len(file)-8-footerLen : len(file)-8]}).structure()
+
+	numRows := int(footer[3].(int64))
+	schema := footer[2].([]any)
+	if n := schema[0].(map[int16]any)[5].(int64); int(n) != len(schema)-1 {
+		t.Fatalf("root has %d children, schema has %d columns", n, len(schema)-1)
+	}
+	names := make([]string, 0)
+	for _, element := range schema[1:] {
+		names = append(names, element.(map[int16]any)[4].(string))
+	}
+
+	rows := make([]parquetTestRow, numRows)
+	rowGroup := footer[4].([]any)[0].(map[int16]any)
+	if n := rowGroup[3].(int64); int(n) != numRows {
+		t.Fatalf("row group has %d rows, file has %d", n, numRows)
+	}
+	for i, chunk := range rowGroup[1].([]any) {
+		meta := chunk.(map[int16]any)[3].(map[int16]any)
+		offset := int(meta[9].(int64))
+		pageReader := &thriftReader{data: file, pos: offset}
+		page := pageReader.structure()
+		data := file[pageReader.pos : pageReader.pos+int(page[3].(int64))]
+		if n := page[5].(map[int16]any)[1].(int64); int(n) != numRows {
+			t.Fatalf("page of %s has %d values", names[i], n)
+		}
+
+		field := reflect.ValueOf(&rows).Elem()
+		for row := 0; row < numRows; row++ {
+			v := field.Index(row).Field(i)
+			switch meta[1].(int64) {
+			case typeBoolean:
+				v.SetBool(data[row/8]&(1<<(row%8)) != 0)
+			case typeInt64:
+				v.SetInt(int64(binary.LittleEndian.Uint64(data[row*8:])))
+			case typeDouble:
+				v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(data[row*8:])))
+			case typeByteArray:
+				n := int(binary.LittleEndian.Uint32(data))
+				v.SetString(string(data[4 : 4+n]))
+				data = data[4+n:]
+			}
+		}
+	}
+	return names, rows
+}
+
+func TestWriteParquetRoundTrip(t *testing.T) {
+	// More than 8 rows so that booleans span several bytes
+	rows := make([]parquetTestRow, 0)
+	for i := range 11 {
+		rows = append(rows, parquetTestRow{
+			Name:  fmt.Sprintf("joueur %d é", i),
+			Count: int64(i*1000 - 3),
+			Ratio: float64(i) / 3,
+			Won:   i%3 == 0,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, rows); err != nil {
+		t.Fatal(err)
+	}
+	names, got := readParquet(t, buf.Bytes())
+	if want := []string{"name", "count", "ratio", "won"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("columns = %v, want %v", names, want)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("rows = %v, want %v", got, rows)
+	}
+}
+
+func TestWriteParquetEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, []parquetTestRow{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, rows := readParquet(t, buf.Bytes()); len(rows) != 0 {
+		t.Errorf("got %d rows, want none", len(rows))
+	}
+}
+
+func TestWriteParquetUnsupportedColumn(t *testing.T) {
+	type row struct {
+		Count int `json:"count"`
+	}
+	if err := writeParquet(&bytes.Buffer{}, []row{{1}}); err == nil {
+		t.Error("int columns should be refused")
+	}
+}
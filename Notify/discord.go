@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// Posts in a channel through the bot's session
+type Discord struct {
+	Session   *discordgo.Session
+	ChannelID string
+}
+
+func (d *Discord) Send(content string, replyTo string) (string, error) {
+	return d.SendComponents(content, replyTo, "", nil)
+}
+
+func (d *Discord) SendComponents(content string, replyTo string, mentions string, components []discordgo.MessageComponent, files ...*discordgo.File) (string, error) {
+	data := &discordgo.MessageSend{Content: mentions + content, Components: components, Files: files}
+	if replyTo != "" {
+		data.Reference = &discordgo.MessageReference{MessageID: replyTo, ChannelID: d.ChannelID}
+	}
+	sent, err := d.Session.ChannelMessageSendComplex(d.ChannelID, data)
+	if err != nil {
+		return "", err
+	}
+	return sent.ID, nil
+}
+
+func (d *Discord) Edit(id string, content string) error {
+	_, err := d.Session.ChannelMessageEdit(d.ChannelID, id, content)
+	return err
+}
+
+func (d *Discord) Pin(id string) error {
+	return d.Session.ChannelMessagePin(d.ChannelID, id)
+}
+
+func (d *Discord) Name() string {
+	return "discord:" + d.ChannelID
+}
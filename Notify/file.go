@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Appends messages as JSON lines to a file
+type File struct {
+	mu   sync.Mutex
+	Path string
+}
+
+type fileRecord struct {
+	Time    time.Time `json:"time"`
+	ID      string    `json:"id"`
+	ReplyTo string    `json:"replyTo,omitempty"`
+	Edit    bool      `json:"edit,omitempty"` // replaces the content of message ID
+	Content string    `json:"content"`
+}
+
+func (f *File) write(record fileRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+func (f *File) Send(content string, replyTo string) (string, error) {
+	now := time.Now()
+	id := strconv.FormatInt(now.UnixNano(), 10)
+	return id, f.write(fileRecord{now, id, replyTo, false, content})
+}
+
+func (f *File) Edit(id string, content string) error {
+	return f.write(fileRecord{time.Now(), id, "", true, content})
+}
+
+func (f *File) Name() string {
+	return "file:" + f.Path
+}
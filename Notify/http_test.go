@@ -0,0 +1,266 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   map[string]any
+}
+
+// Server recording the requests it gets and answering them with status and response
+func newServer(t *testing.T, status int, response string) (*httptest.Server, *[]request) {
+	t.Helper()
+	requests := make([]request, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		body := make(map[string]any)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("body isn't a JSON object: %s", data)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		requests = append(requests, request{r.Method, r.URL.Path, r.URL.RawQuery, r.Header, body})
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestDiscordWebhook(t *testing.T) {
+	server, requests := newServer(t, http.StatusOK, `{"id":"42"}`)
+	d := &DiscordWebhook{URL: server.URL + "/api/webhooks/1/token"}
+
+	id, err := d.Send("hello", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "42" {
+		t.Errorf("id = %q, want 42", id)
+	}
+	if err := d.Edit(id, "edited"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(*requests))
+	}
+	send, edit := (*requests)[0], (*requests)[1]
+	if send.Method != "POST" || send.Path != "/api/webhooks/1/token" || send.Query != "wait=true" {
+		t.Errorf("send: %s %s?%s", send.Method, send.Path, send.Query)
+	}
+	if send.Body["content"] != "hello" {
+		t.Errorf("send body: %v", send.Body)
+	}
+	if edit.Method != "PATCH" || edit.Path != "/api/webhooks/1/token/messages/42" {
+		t.Errorf("edit: %s %s", edit.Method, edit.Path)
+	}
+	if edit.Body["content"] != "edited" {
+		t.Errorf("edit body: %v", edit.Body)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	server, requests := newServer(t, http.StatusNoContent, "")
+	w := &Webhook{URL: server.URL, Headers: map[string]string{"Authorization": "secret"}}
+
+	first, err := w.Send("hello", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := w.Send("reply", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == "" || first == second {
+		t.Errorf("ids %q and %q should be distinct and not empty", first, second)
+	}
+	if err := w.Edit(first, "edited"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []map[string]any{
+		{"event": "message", "id": first, "content": "hello"},
+		{"event": "message", "id": second, "content": "reply", "replyTo": first},
+		{"event": "edit", "id": first, "content": "edited"},
+	}
+	if len(*requests) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(*requests), len(want))
+	}
+	for i, r := range *requests {
+		if r.Method != "POST" || r.Header.Get("Authorization") != "secret" {
+			t.Errorf("request %d: %s with Authorization %q", i, r.Method, r.Header.Get("Authorization"))
+		}
+		for key, value := range want[i] {
+			if r.Body[key] != value {
+				t.Errorf("request %d: %s = %v, want %v", i, key, r.Body[key], value)
+			}
+		}
+		if _, ok := r.Body["time"]; !ok {
+			t.Errorf("request %d has no time", i)
+		}
+	}
+}
+
+func TestSlack(t *testing.T) {
+	server, requests := newServer(t, http.StatusOK, "ok")
+	s := &Slack{URL: server.URL}
+
+	id, err := s.Send("hello", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "" {
+		t.Errorf("id = %q, want none", id)
+	}
+	if len(*requests) != 1 || (*requests)[0].Body["text"] != "hello" {
+		t.Errorf("requests: %v", *requests)
+	}
+	if err := s.Edit("", "edited"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Edit error = %v, want ErrUnsupported", err)
+	}
+	if CanEdit(s) || !CanEdit(Multi{s, &Webhook{}}) || CanEdit(Multi{s}) {
+		t.Error("only notifiers with an editable member can edit")
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	server, requests := newServer(t, http.StatusOK, `{"event_id":"$event"}`)
+	m := &Matrix{Homeserver: server.URL, RoomID: "!room:example.org", AccessToken: "token"}
+
+	id, err := m.Send("hello", "$previous")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "$event" {
+		t.Errorf("id = %q, want $event", id)
+	}
+	if err := m.Edit(id, "edited"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(*requests))
+	}
+	send, edit := (*requests)[0], (*requests)[1]
+	prefix := "/_matrix/client/v3/rooms/!room:example.org/send/m.room.message/"
+	for _, r := range *requests {
+		if r.Method != "PUT" || !strings.HasPrefix(r.Path, prefix) {
+			t.Errorf("%s %s", r.Method, r.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+	}
+	if send.Path == edit.Path {
+		t.Error("transaction IDs should differ")
+	}
+	if send.Body["body"] != "hello" || send.Body["msgtype"] != "m.text" {
+		t.Errorf("send body: %v", send.Body)
+	}
+	relation, _ := send.Body["m.relates_to"].(map[string]any)
+	if reply, _ := relation["m.in_reply_to"].(map[string]any); reply["event_id"] != "$previous" {
+		t.Errorf("send relation: %v", relation)
+	}
+	relation, _ = edit.Body["m.relates_to"].(map[string]any)
+	if relation["rel_type"] != "m.replace" || relation["event_id"] != "$event" {
+		t.Errorf("edit relation: %v", relation)
+	}
+	if content, _ := edit.Body["m.new_content"].(map[string]any); content["body"] != "edited" {
+		t.Errorf("edit new content: %v", edit.Body["m.new_content"])
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	server, _ := newServer(t, http.StatusInternalServerError, `{"error":"down"}`)
+	notifiers := []Notifier{
+		&DiscordWebhook{URL: server.URL},
+		&Webhook{URL: server.URL},
+		&Slack{URL: server.URL},
+		&Matrix{Homeserver: server.URL, RoomID: "!room:example.org"},
+	}
+	for _, n := range notifiers {
+		if _, err := n.Send("hello", ""); err == nil || !strings.Contains(err.Error(), "500") {
+			t.Errorf("%s: Send error = %v, want the status code", n.Name(), err)
+		}
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	server, _ := newServer(t, http.StatusOK, "")
+	server.Close()
+	if _, err := (&Webhook{URL: server.URL}).Send("hello", ""); err == nil {
+		t.Error("Send should fail when the server is down")
+	}
+}
+
+func TestInvalidResponse(t *testing.T) {
+	server, _ := newServer(t, http.StatusOK, "not json")
+	if _, err := (&DiscordWebhook{URL: server.URL}).Send("hello", ""); err == nil {
+		t.Error("Send should fail on a response that isn't JSON")
+	}
+}
+
+type componentRecorder struct {
+	name  string
+	sent  []string
+	files []string
+}
+
+func (c *componentRecorder) Send(content string, replyTo string) (string, error) {
+	return c.SendComponents(content, replyTo, "", nil)
+}
+
+func (c *componentRecorder) SendComponents(content string, replyTo string, mentions string, components []discordgo.MessageComponent, files ...*discordgo.File) (string, error) {
+	c.sent = append(c.sent, mentions+content)
+	for _, f := range files {
+		data, err := io.ReadAll(f.Reader)
+		if err != nil {
+			return "", err
+		}
+		c.files = append(c.files, string(data))
+	}
+	return "1", nil
+}
+
+func (c *componentRecorder) Edit(id string, content string) error { return nil }
+func (c *componentRecorder) Name() string                         { return c.name }
+
+func TestMultiSendComponents(t *testing.T) {
+	server, requests := newServer(t, http.StatusNoContent, "")
+	first, second := &componentRecorder{name: "first"}, &componentRecorder{name: "second"}
+	m := Multi{first, &Webhook{URL: server.URL}, second}
+
+	file := &discordgo.File{Name: "card.png", Reader: strings.NewReader("png")}
+	if _, err := m.SendComponents("hello", "", "<@1> ", nil, file); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*componentRecorder{first, second} {
+		if len(c.sent) != 1 || c.sent[0] != "<@1> hello" {
+			t.Errorf("%s sent %v", c.name, c.sent)
+		}
+		if len(c.files) != 1 || c.files[0] != "png" {
+			t.Errorf("%s got files %v", c.name, c.files)
+		}
+	}
+	if len(*requests) != 1 || (*requests)[0].Body["content"] != "hello" {
+		t.Errorf("webhook requests: %v", *requests)
+	}
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Posts in a Matrix room with the client-server API
+type Matrix struct {
+	Homeserver  string // e.g. https://matrix.org
+	RoomID      string
+	AccessToken string
+	txnID       atomic.Int64
+}
+
+type matrixEvent struct {
+	EventID string `json:"event_id"`
+}
+
+type matrixRelation struct {
+	RelType   string            `json:"rel_type,omitempty"`
+	EventID   string            `json:"event_id,omitempty"`
+	InReplyTo map[string]string `json:"m.in_reply_to,omitempty"`
+}
+
+type matrixMessage struct {
+	MsgType    string          `json:"msgtype"`
+	Body       string          `json:"body"`
+	NewContent *matrixMessage  `json:"m.new_content,omitempty"`
+	RelatesTo  *matrixRelation `json:"m.relates_to,omitempty"`
+}
+
+func (m *Matrix) send(message matrixMessage) (string, error) {
+	// Transaction IDs make retries idempotent, they must be unique per token
+	txnID := strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.FormatInt(m.txnID.Add(1), 10)
+	endpoint := m.Homeserver + "/_matrix/client/v3/rooms/" + url.PathEscape(m.RoomID) + "/send/m.room.message/" + txnID
+	headers := map[string]string{"Authorization": "Bearer " + m.AccessToken}
+
+	var event matrixEvent
+	err := sendJSON("PUT", endpoint, headers, message, &event)
+	return event.EventID, err
+}
+
+func (m *Matrix) Send(content string, replyTo string) (string, error) {
+	message := matrixMessage{MsgType: "m.text", Body: content}
+	if replyTo != "" {
+		message.RelatesTo = &matrixRelation{InReplyTo: map[string]string{"event_id": replyTo}}
+	}
+	return m.send(message)
+}
+
+func (m *Matrix) Edit(id string, content string) error {
+	_, err := m.send(matrixMessage{
+		MsgType:    "m.text",
+		Body:       "* " + content,
+		NewContent: &matrixMessage{MsgType: "m.text", Body: content},
+		RelatesTo:  &matrixRelation{RelType: "m.replace", EventID: id},
+	})
+	return err
+}
+
+func (m *Matrix) Name() string {
+	return "matrix:" + m.RoomID
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Posts every message to several notifiers. Message IDs are JSON objects
+// mapping each notifier's name to its own message ID.
+type Multi []Notifier
+
+func decodeIDs(id string) map[string]string {
+	ids := make(map[string]string)
+	if id != "" {
+		_ = json.Unmarshal([]byte(id), &ids)
+	}
+	return ids
+}
+
+// Succeeds if at least one notifier did
+func (m Multi) Send(content string, replyTo string) (string, error) {
+	return m.SendComponents(content, replyTo, "", nil)
+}
+
+// Notifiers that can't post the extras get the content only. Files are read
+// once, each notifier gets its own copy.
+func (m Multi) SendComponents(content string, replyTo string, mentions string, components []discordgo.MessageComponent, files ...*discordgo.File) (string, error) {
+	contents := make([][]byte, len(files))
+	for i, f := range files {
+		var err error
+		if contents[i], err = io.ReadAll(f.Reader); err != nil {
+			return "", err
+		}
+	}
+	replies := decodeIDs(replyTo)
+	ids := make(map[string]string)
+	errs := make([]error, 0)
+	for _, n := range m {
+		var id string
+		var err error
+		if sender, ok := n.(ComponentSender); ok {
+			copies := make([]*discordgo.File, len(files))
+			for i, f := range files {
+				copies[i] = &discordgo.File{Name: f.Name, ContentType: f.ContentType, Reader: bytes.NewReader(contents[i])}
+			}
+			id, err = sender.SendComponents(content, replies[n.Name()], mentions, components, copies...)
+		} else {
+			id, err = n.Send(content, replies[n.Name()])
+		}
+		if err != nil {
+			log.Println("couldn't send message with " + n.Name() + ": " + err.Error())
+			errs = append(errs, err)
+			continue
+		}
+		ids[n.Name()] = id
+	}
+	if len(ids) == 0 && len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
+	data, err := json.Marshal(ids)
+	return string(data), err
+}
+
+func (m Multi) Edit(id string, content string) error {
+	ids := decodeIDs(id)
+	errs := make([]error, 0)
+	for _, n := range m {
+		childID, ok := ids[n.Name()]
+		if !ok {
+			continue
+		}
+		if err := n.Edit(childID, content); err != nil && !errors.Is(err, ErrUnsupported) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Editable if one of the notifiers is, the others keep the first version
+func (m Multi) CanEdit() bool {
+	return slices.ContainsFunc(m, CanEdit)
+}
+
+func (m Multi) Pin(id string) error {
+	ids := decodeIDs(id)
+	errs := make([]error, 0)
+	for _, n := range m {
+		if pinner, ok := n.(Pinner); ok && ids[n.Name()] != "" {
+			errs = append(errs, pinner.Pin(ids[n.Name()]))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m Multi) Name() string {
+	names := make([]string, 0, len(m))
+	for _, n := range m {
+		names = append(names, n.Name())
+	}
+	return strings.Join(names, ",")
+}
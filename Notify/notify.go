@@ -0,0 +1,115 @@
+package notify
+
+/* Destinations of the messages posted by the poller */
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type Notifier interface {
+	// Posts the message, as a reply to an earlier one when replyTo isn't
+	// empty. Returns the ID of the posted message, empty if the destination
+	// has none.
+	Send(content string, replyTo string) (string, error)
+	// Replaces the content of a posted message
+	Edit(id string, content string) error
+	// Identifies the destination, to remember its message IDs
+	Name() string
+}
+
+// Destinations able to pin messages
+type Pinner interface {
+	Pin(id string) error
+}
+
+// Destinations able to post mentions before the message, components such as
+// buttons under it, and attached files
+type ComponentSender interface {
+	SendComponents(content string, replyTo string, mentions string, components []discordgo.MessageComponent, files ...*discordgo.File) (string, error)
+}
+
+// Destinations whose messages can't always be edited, the others can
+type EditChecker interface {
+	CanEdit() bool
+}
+
+var ErrUnsupported = errors.New("not supported by this notifier")
+
+// Whether the notifier's messages can be edited
+func CanEdit(n Notifier) bool {
+	if checker, ok := n.(EditChecker); ok {
+		return checker.CanEdit()
+	}
+	return true
+}
+
+// Notifiers selected by the NOTIFIER environment variable, comma separated:
+// discord (default), stdout, file (NOTIFY_FILE), discord-webhook
+// (DISCORD_WEBHOOK_URL), webhook (WEBHOOK_URL, WEBHOOK_AUTHORIZATION), slack
+// (SLACK_WEBHOOK_URL), matrix (MATRIX_HOMESERVER, MATRIX_ROOM_ID, MATRIX_TOKEN)
+// or none
+func Kind() string {
+	if kind := os.Getenv("NOTIFIER"); kind != "" {
+		return strings.ToLower(kind)
+	}
+	return "discord"
+}
+
+func FilePath() string {
+	if path := os.Getenv("NOTIFY_FILE"); path != "" {
+		return path
+	}
+	return "notifications.jsonl"
+}
+
+func getEnv(names ...string) ([]string, error) {
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		value := os.Getenv(name)
+		if value == "" {
+			return nil, fmt.Errorf("%s must be set", name)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Builds a notifier posting over HTTP from its environment variables
+func FromEnv(kind string) (Notifier, error) {
+	switch kind {
+	case "discord-webhook":
+		env, err := getEnv("DISCORD_WEBHOOK_URL")
+		if err != nil {
+			return nil, err
+		}
+		return &DiscordWebhook{URL: strings.TrimSuffix(env[0], "/")}, nil
+	case "webhook":
+		env, err := getEnv("WEBHOOK_URL")
+		if err != nil {
+			return nil, err
+		}
+		webhook := &Webhook{URL: env[0]}
+		if auth := os.Getenv("WEBHOOK_AUTHORIZATION"); auth != "" {
+			webhook.Headers = map[string]string{"Authorization": auth}
+		}
+		return webhook, nil
+	case "slack":
+		env, err := getEnv("SLACK_WEBHOOK_URL")
+		if err != nil {
+			return nil, err
+		}
+		return &Slack{URL: env[0]}, nil
+	case "matrix":
+		env, err := getEnv("MATRIX_HOMESERVER", "MATRIX_ROOM_ID", "MATRIX_TOKEN")
+		if err != nil {
+			return nil, err
+		}
+		return &Matrix{Homeserver: strings.TrimSuffix(env[0], "/"), RoomID: env[1], AccessToken: env[2]}, nil
+	}
+	return nil, errors.New("unknown notifier: " + kind)
+}
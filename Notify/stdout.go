@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Dry run: prints messages instead of posting them
+type Stdout struct {
+	mu     sync.Mutex
+	Writer io.Writer // os.Stdout if nil
+	lastID int
+}
+
+func (s *Stdout) print(header string, content string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.Writer
+	if w == nil {
+		w = os.Stdout
+	}
+	s.lastID++
+	id := "dry-run-" + strconv.Itoa(s.lastID)
+	fmt.Fprintf(w, "----- [%s] %s -----\n%s\n", id, header, content)
+	return id
+}
+
+func (s *Stdout) Send(content string, replyTo string) (string, error) {
+	header := "message"
+	if replyTo != "" {
+		header = "reply to " + replyTo
+	}
+	return s.print(header, content), nil
+}
+
+func (s *Stdout) Edit(id string, content string) error {
+	s.print("edit of "+id, content)
+	return nil
+}
+
+func (s *Stdout) Name() string {
+	return "stdout"
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Discord webhook, messages can be edited but not posted as replies
+type DiscordWebhook struct {
+	URL string
+}
+
+type discordWebhookMessage struct {
+	ID string `json:"id"`
+}
+
+func (d *DiscordWebhook) Send(content string, replyTo string) (string, error) {
+	var sent discordWebhookMessage
+	err := sendJSON("POST", d.URL+"?wait=true", nil, map[string]string{"content": content}, &sent)
+	return sent.ID, err
+}
+
+func (d *DiscordWebhook) Edit(id string, content string) error {
+	return sendJSON("PATCH", d.URL+"/messages/"+id, nil, map[string]string{"content": content}, nil)
+}
+
+func (d *DiscordWebhook) Name() string {
+	return "discord-webhook"
+}
+
+// Generic outgoing webhook: every message and edit is POSTed as a webhookEvent
+type Webhook struct {
+	URL     string
+	Headers map[string]string // e.g. authentication
+	lastID  atomic.Int64
+}
+
+type webhookEvent struct {
+	Event   string    `json:"event"` // "message" or "edit"
+	ID      string    `json:"id"`
+	ReplyTo string    `json:"replyTo,omitempty"`
+	Content string    `json:"content"`
+	Time    time.Time `json:"time"`
+}
+
+func (w *Webhook) Send(content string, replyTo string) (string, error) {
+	id := strconv.FormatInt(time.Now().UnixNano()+w.lastID.Add(1), 10)
+	return id, sendJSON("POST", w.URL, w.Headers, webhookEvent{"message", id, replyTo, content, time.Now()}, nil)
+}
+
+func (w *Webhook) Edit(id string, content string) error {
+	return sendJSON("POST", w.URL, w.Headers, webhookEvent{"edit", id, "", content, time.Now()}, nil)
+}
+
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+// Slack incoming webhooks and compatible ones (Mattermost, Rocket.Chat...).
+// They return no message ID, so messages can't be edited or replied to.
+type Slack struct {
+	URL string
+}
+
+func (s *Slack) Send(content string, replyTo string) (string, error) {
+	return "", sendJSON("POST", s.URL, nil, map[string]string{"text": content}, nil)
+}
+
+func (s *Slack) Edit(id string, content string) error {
+	return ErrUnsupported
+}
+
+func (s *Slack) CanEdit() bool {
+	return false
+}
+
+func (s *Slack) Name() string {
+	return "slack"
+}
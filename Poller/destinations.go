@@ -0,0 +1,48 @@
+package poller
+
+/* Where games are announced: the main notifier and each guild's channel */
+
+import (
+	"slices"
+
+	api "github.com/Nvim/silverstalker/Api"
+	bot "github.com/Nvim/silverstalker/Bot"
+	notify "github.com/Nvim/silverstalker/Notify"
+)
+
+type destination struct {
+	notifier notify.Notifier
+	options  api.ReportOptions
+	players  []*api.PlayerInfo // tracked players announced there
+}
+
+// The main notifier announces the group's own players, guilds with an
+// announcement channel only the players they track
+func destinations(players []*api.PlayerInfo) []destination {
+	dests := make([]destination, 0)
+	own := slices.DeleteFunc(slices.Clone(players), func(p *api.PlayerInfo) bool { return p.Guild != "" })
+	if len(own) > 0 {
+		dests = append(dests, destination{Notifier, api.DefaultReportOptions, own})
+	}
+	if bot.Bot == nil {
+		return dests
+	}
+	for _, guild := range bot.GuildsTracking(players) {
+		tracked := guild.TrackedAmong(players)
+		if len(tracked) == 0 {
+			continue
+		}
+		n := &notify.Discord{Session: bot.Bot, ChannelID: guild.ChannelID}
+		if i := slices.IndexFunc(dests, func(d destination) bool { return d.notifier.Name() == n.Name() }); i != -1 {
+			// Already a destination, only its players are added
+			for _, p := range tracked {
+				if !slices.Contains(dests[i].players, p) {
+					dests[i].players = append(dests[i].players, p)
+				}
+			}
+			continue
+		}
+		dests = append(dests, destination{n, guild.Options(), tracked})
+	}
+	return dests
+}
@@ -0,0 +1,115 @@
+package poller
+
+/* Announces ranked games as soon as tracked players start them */
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	api "github.com/Nvim/silverstalker/Api"
+	storage "github.com/Nvim/silverstalker/Storage"
+)
+
+type liveAnnouncement struct {
+	msgIDs map[string]string // by notifier name
+	time   time.Time
+}
+
+// Games that never get reported, such as remakes or games of untracked
+// queues, are forgotten after this long
+const liveAnnouncementTTL = 3 * time.Hour
+
+// Announcements of games in progress, keyed by future match ID
+var liveAnnouncements = make(map[string]liveAnnouncement)
+
+// Checks whether the player is in a ranked game and announces it if it's new.
+// Returns the game, nil if the player isn't in a ranked game.
+func pollLive(p *api.PlayerInfo, players []*api.PlayerInfo) (*api.CurrentGameInfo, error) {
+	game, err := p.GetActiveGame()
+	if err != nil {
+		return nil, err
+	}
+	if game == nil || !game.IsRanked() {
+		return nil, nil
+	}
+	if !api.Allows(p.PUUID, api.LiveAnnouncement, false) {
+		return game, nil
+	}
+	if !claimLiveAnnouncement(game.MatchID()) {
+		return game, nil
+	}
+
+	// The game is claimed once for all destinations: each one tracking someone
+	// in it is announced, not only those of the polled player
+	msgIDs := make(map[string]string)
+	for _, dest := range destinations(players) {
+		announced := api.AllowingPlayers(api.GetTrackedInGame(game, dest.players), api.LiveAnnouncement, false)
+		if len(announced) == 0 {
+			continue
+		}
+		msg, err := api.GetLiveGameString(game, announced)
+		if err != nil {
+			log.Println("Error announcing live game to " + dest.notifier.Name() + ": " + err.Error())
+			continue
+		}
+		log.Println("Live game: " + msg)
+		msgID, err := dest.notifier.Send(msg, "")
+		if err != nil {
+			log.Println("Error announcing live game to " + dest.notifier.Name() + ": " + err.Error())
+			continue
+		}
+		msgIDs[dest.notifier.Name()] = msgID
+		saveAnnouncement(game.MatchID(), dest.notifier, api.LiveAnnouncement, msgID)
+	}
+	if len(msgIDs) == 0 {
+		popLiveAnnouncement(game.MatchID())
+		return game, errors.New("live game couldn't be announced")
+	}
+
+	mu.Lock()
+	liveAnnouncements[game.MatchID()] = liveAnnouncement{msgIDs, time.Now()}
+	mu.Unlock()
+	return game, nil
+}
+
+// Reserves the announcement of the game, returns false if it's already done
+func claimLiveAnnouncement(matchID string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	for id, a := range liveAnnouncements {
+		if time.Since(a.time) > liveAnnouncementTTL {
+			delete(liveAnnouncements, id)
+		}
+	}
+	if _, ok := liveAnnouncements[matchID]; ok {
+		return false
+	}
+	liveAnnouncements[matchID] = liveAnnouncement{time: time.Now()}
+	return true
+}
+
+// Returns the live announcements of the match by notifier name and forgets
+// them. Those made before a restart are read from the store.
+func popLiveAnnouncement(matchID string) map[string]string {
+	mu.Lock()
+	live, ok := liveAnnouncements[matchID]
+	delete(liveAnnouncements, matchID)
+	mu.Unlock()
+	if ok {
+		return live.msgIDs
+	}
+
+	announcements, err := storage.Store.Announcements(matchID)
+	if err != nil {
+		log.Println("Error reading announcements of " + matchID + ": " + err.Error())
+		return nil
+	}
+	msgIDs := make(map[string]string)
+	for _, a := range announcements {
+		if a.Kind == api.LiveAnnouncement {
+			msgIDs[a.Destination] = a.MessageID
+		}
+	}
+	return msgIDs
+}
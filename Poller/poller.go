@@ -0,0 +1,302 @@
+package poller
+
+/* Fetches tracked players' new games and reports them */
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	api "github.com/Nvim/silverstalker/Api"
+	bot "github.com/Nvim/silverstalker/Bot"
+	card "github.com/Nvim/silverstalker/Card"
+	notify "github.com/Nvim/silverstalker/Notify"
+	storage "github.com/Nvim/silverstalker/Storage"
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	Notifier    notify.Notifier             = &notify.Stdout{} // where reports are posted
+	mu          sync.Mutex                                     // guards the maps below
+	latestMatch = make(map[string]string)                      // PUUID -> latest known match ID
+	reported    = make(map[string]bool)                        // match IDs already announced
+	initialized = make(map[string]bool)                        // PUUIDs whose latest match is known
+	pending     = make(map[string][]string)                    // PUUID -> matches whose report failed, oldest first
+	attempts    = make(map[string]int)                         // match ID -> failed report attempts
+)
+
+// Failed reports are retried on the following polls, up to this many times
+const maxReportAttempts = 5
+
+// Remembers each player's latest match so that only newer games get reported
+func Init(players []*api.PlayerInfo) error {
+	for _, p := range players {
+		if err := initPlayer(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func initPlayer(p *api.PlayerInfo) error {
+	matchIDs, err := p.GetLatestMatches()
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	initialized[p.PUUID] = true
+	if len(matchIDs) > 0 {
+		latestMatch[p.PUUID] = matchIDs[0]
+		reported[matchIDs[0]] = true
+	}
+	return nil
+}
+
+// Returns the matches played by the player since the last poll, oldest first
+func newMatches(p *api.PlayerInfo) (api.MatchID, error) {
+	matchIDs, err := p.GetLatestMatches()
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(matchIDs) == 0 || matchIDs[0] == latestMatch[p.PUUID] {
+		return nil, nil
+	}
+
+	idx := slices.Index(matchIDs, latestMatch[p.PUUID])
+	if idx == -1 {
+		// Latest known match is too old, only consider the newest one
+		idx = 1
+	}
+	latestMatch[p.PUUID] = matchIDs[0]
+
+	fresh := slices.Clone(matchIDs[:idx])
+	slices.Reverse(fresh)
+	return fresh, nil
+}
+
+// Fetches the player's new games and sends one report per game, along with
+// the games whose report previously failed. Returns the number of new games found.
+func pollMatches(p *api.PlayerInfo, players []*api.PlayerInfo) (int, error) {
+	mu.Lock()
+	known := initialized[p.PUUID]
+	mu.Unlock()
+	if !known {
+		// Started being tracked while running, only report its next games
+		return 0, initPlayer(p)
+	}
+
+	matchIDs, err := newMatches(p)
+	if err != nil {
+		return 0, err
+	}
+	if len(matchIDs) == 0 {
+		log.Println("No new match data for " + p.RiotID())
+	}
+	for _, id := range append(takePending(p.PUUID), matchIDs...) {
+		if !claimReport(id) {
+			continue
+		}
+		if err := report(id, players); err != nil {
+			log.Println("Error reporting match " + id + ": " + err.Error())
+			failReport(p.PUUID, id)
+			continue
+		}
+		mu.Lock()
+		delete(attempts, id)
+		mu.Unlock()
+	}
+	return len(matchIDs), nil
+}
+
+// Returns the player's matches to report again and forgets them
+func takePending(puuid string) []string {
+	mu.Lock()
+	defer mu.Unlock()
+	matchIDs := pending[puuid]
+	delete(pending, puuid)
+	return matchIDs
+}
+
+// Queues the match to be reported on the player's next poll, unless it failed too often
+func failReport(puuid string, matchID string) {
+	mu.Lock()
+	defer mu.Unlock()
+	attempts[matchID]++
+	if attempts[matchID] >= maxReportAttempts {
+		log.Println("Giving up on reporting match " + matchID)
+		delete(attempts, matchID)
+		return
+	}
+	delete(reported, matchID)
+	pending[puuid] = append(pending[puuid], matchID)
+}
+
+// Marks the match as reported, returns false if it already was
+func claimReport(matchID string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if reported[matchID] {
+		return false
+	}
+	reported[matchID] = true
+	return true
+}
+
+func report(matchID string, players []*api.PlayerInfo) error {
+	match, err := api.GetMatchInfo(matchID)
+	if err != nil {
+		return err
+	}
+	log.Println("New game ID: ", matchID)
+	if raw, ok := api.ResponseCache.Get("match/" + matchID); ok {
+		if err := storage.Store.SaveMatch(raw); err != nil {
+			log.Println("Error storing match " + matchID + ": " + err.Error())
+		}
+	}
+
+	tracked := api.GetTrackedInMatch(match, players)
+	for _, p := range tracked {
+		if err := api.AddToHistory(p.PUUID, matchID); err != nil {
+			log.Println("Error saving match history of " + p.RiotID() + ": " + err.Error())
+		}
+		if err := api.SaveMasterySnapshot(p.PUUID); err != nil {
+			log.Println("Error saving mastery snapshot of " + p.RiotID() + ": " + err.Error())
+		}
+		if _, err := storage.SaveLeagueSnapshot(storage.Store, p.PUUID); err != nil {
+			log.Println("Error saving rank snapshot of " + p.RiotID() + ": " + err.Error())
+		}
+	}
+	if len(tracked) == 0 {
+		return nil
+	}
+	for _, team := range api.SplitByTeam(match, tracked) {
+		if len(team) < 2 {
+			continue
+		}
+		if _, err = api.RecordGroupMatch(match, team); err != nil {
+			log.Println("Error saving group record: " + err.Error())
+		}
+	}
+
+	// Stored for the report buttons
+	if _, err := api.GetMatchTimeline(matchID); err != nil {
+		log.Println("Error getting timeline of " + matchID + ": " + err.Error())
+	}
+
+	// Only fail, so that the game is retried, if it couldn't be announced anywhere
+	replies := popLiveAnnouncement(matchID)
+	dests := destinations(players)
+	errs := make([]error, 0)
+	for _, dest := range dests {
+		if err := dest.report(match, replies[dest.notifier.Name()]); err != nil {
+			log.Println("Error reporting match to " + dest.notifier.Name() + ": " + err.Error())
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == len(dests) {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+func (dest destination) report(match *api.Match, replyTo string) error {
+	tracked := api.AllowingInMatch(match, dest.players, api.ReportAnnouncement)
+	roastable := api.AllowingInMatch(match, tracked, api.RoastAnnouncement)
+	options := dest.options
+	if len(roastable) < len(tracked) {
+		options.Roast = 0
+	}
+	var msg string
+	var err error
+	switch len(tracked) {
+	case 0:
+		return nil
+	case 1:
+		msg, err = api.GetMatchReport(match, tracked[0], options)
+	default:
+		msg, err = api.GetGroupMatchReport(match, tracked, options)
+	}
+	if err != nil {
+		return err
+	}
+	log.Println("Stats: " + msg)
+	var msgID string
+	if sender, ok := dest.notifier.(notify.ComponentSender); ok {
+		var files []*discordgo.File
+		if options.Card {
+			files = renderCards(match, tracked, options)
+		}
+		msgID, err = sender.SendComponents(msg, replyTo, bot.Mentions(tracked), bot.ReportComponents(match, tracked), files...)
+	} else {
+		msgID, err = dest.notifier.Send(msg, replyTo)
+	}
+	if err != nil {
+		return err
+	}
+	saveAnnouncement(match.Metadata.MatchID, dest.notifier, api.ReportAnnouncement, msgID)
+
+	if dest.options.Scoreboard && api.AllAllowInMatch(match, api.TrackedPlayers(), api.ReportAnnouncement) {
+		scoreboard := api.GetScoreboardString(api.GetScoreboard(match, tracked, true))
+		if _, err := dest.notifier.Send(scoreboard, msgID); err != nil {
+			log.Println("Error sending scoreboard: " + err.Error())
+		}
+	}
+
+	if dest.options.Roast > 0 {
+		dest.sendTiltAlerts(roastable)
+	}
+
+	if err := bot.UpdatePinnedLeaderboard(dest.players, dest.notifier); err != nil {
+		log.Println("Error updating pinned leaderboard: " + err.Error())
+	}
+	return nil
+}
+
+// A card per player, those that fail to render are left out
+func renderCards(match *api.Match, players []*api.PlayerInfo, options api.ReportOptions) []*discordgo.File {
+	files := make([]*discordgo.File, 0, len(players))
+	for _, p := range players {
+		png, err := card.Render(match, p.PUUID, options)
+		if err != nil {
+			log.Println("Error rendering card of " + p.RiotID() + ": " + err.Error())
+			continue
+		}
+		files = append(files, &discordgo.File{
+			Name:        "card-" + strings.ReplaceAll(p.GameName, " ", "_") + ".png",
+			ContentType: "image/png",
+			Reader:      bytes.NewReader(png),
+		})
+	}
+	return files
+}
+
+func (dest destination) sendTiltAlerts(tracked []*api.PlayerInfo) {
+	for _, p := range tracked {
+		alert, err := api.GetTiltString(p, api.Thresholds)
+		if err != nil {
+			log.Println("Error checking tilt of " + p.RiotID() + ": " + err.Error())
+			continue
+		}
+		if alert != "" {
+			if _, err := dest.notifier.Send(alert, ""); err != nil {
+				log.Println("Error sending tilt alert: " + err.Error())
+			}
+		}
+	}
+}
+
+// Remembered so that a restart doesn't lose which messages to reply to
+func saveAnnouncement(matchID string, notifier notify.Notifier, kind string, msgID string) {
+	announcement := storage.Announcement{MatchID: matchID, Destination: notifier.Name(), Kind: kind, MessageID: msgID}
+	if err := storage.Store.SaveAnnouncement(announcement); err != nil {
+		log.Println("Error storing announcement of " + matchID + ": " + err.Error())
+	}
+}
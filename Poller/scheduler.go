@@ -0,0 +1,146 @@
+package poller
+
+/* Polls each player on its own adaptive interval */
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	api "github.com/Nvim/silverstalker/Api"
+)
+
+var (
+	LiveInterval   = 1 * time.Minute  // player is in a game
+	ActiveInterval = 3 * time.Minute  // player played recently
+	IdleInterval   = 10 * time.Minute // first interval once idle
+	MaxInterval    = 30 * time.Minute // idle interval stops growing here
+	ActiveWindow   = 1 * time.Hour    // how long a player stays "recently active"
+	MatchDelay     = 15 * time.Minute // how long to wait for the match of an ended game
+)
+
+type playerState struct {
+	player     *api.PlayerInfo
+	nextPoll   time.Time
+	interval   time.Duration
+	lastActive time.Time // last time the player was seen in game or with a new match
+	inGame     bool
+	gameEnded  time.Time // when the player was last seen leaving a game, zero once its match was found
+	polling    bool      // a poll is running for the player
+}
+
+type Scheduler struct {
+	mu      sync.Mutex
+	players []*api.PlayerInfo
+	states  map[string]*playerState // keyed by PUUID
+	wake    chan struct{}
+}
+
+func NewScheduler(players []*api.PlayerInfo) *Scheduler {
+	s := &Scheduler{
+		states: make(map[string]*playerState),
+		wake:   make(chan struct{}, 1),
+	}
+	s.SetPlayers(players)
+	return s
+}
+
+// Replaces the tracked players, new ones are polled right away
+func (s *Scheduler) SetPlayers(players []*api.PlayerInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.players = players
+	states := make(map[string]*playerState)
+	for _, p := range players {
+		if state, ok := s.states[p.PUUID]; ok {
+			state.player = p
+			states[p.PUUID] = state
+			continue
+		}
+		states[p.PUUID] = &playerState{player: p, nextPoll: time.Now(), interval: ActiveInterval}
+	}
+	s.states = states
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Polls players forever, each one in its own goroutine when it's due
+func (s *Scheduler) Run() {
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		next := now.Add(MaxInterval)
+		for _, state := range s.states {
+			if state.polling {
+				continue
+			}
+			if !state.nextPoll.After(now) {
+				state.polling = true
+				go s.poll(state, s.players)
+				continue
+			}
+			if state.nextPoll.Before(next) {
+				next = state.nextPoll
+			}
+		}
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		}
+	}
+}
+
+func (s *Scheduler) poll(state *playerState, players []*api.PlayerInfo) {
+	p := state.player
+	game, err := pollLive(p, players)
+	if err != nil {
+		log.Println("Error getting active game of " + p.RiotID() + ": " + err.Error())
+	}
+	// Also poll while in game: the previous game may have ended since last time
+	found, err := pollMatches(p, players)
+	if err != nil {
+		log.Println("Error getting matches of " + p.RiotID() + ": " + err.Error())
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	if state.inGame && game == nil {
+		state.gameEnded = now
+	}
+	state.inGame = game != nil
+	if state.inGame || found > 0 {
+		state.lastActive = now
+		state.gameEnded = time.Time{}
+	}
+	switch {
+	case state.inGame:
+		state.interval = LiveInterval
+	case !state.gameEnded.IsZero() && now.Sub(state.gameEnded) < MatchDelay:
+		// Game just ended, its match data shows up a few minutes later
+		state.interval = LiveInterval
+	case now.Sub(state.lastActive) < ActiveWindow:
+		state.interval = ActiveInterval
+	case state.interval < IdleInterval:
+		state.interval = IdleInterval
+	default:
+		state.interval = min(state.interval*3/2, MaxInterval)
+	}
+	state.nextPoll = now.Add(state.interval)
+	state.polling = false
+	interval := state.interval
+	s.mu.Unlock()
+
+	log.Println("Next poll of " + p.RiotID() + " in " + interval.String())
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
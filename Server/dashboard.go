@@ -0,0 +1,256 @@
+package server
+
+/* Server rendered dashboard, templates and assets are embedded in the binary */
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	api "github.com/Nvim/silverstalker/Api"
+	card "github.com/Nvim/silverstalker/Card"
+	static "github.com/Nvim/silverstalker/Static"
+	storage "github.com/Nvim/silverstalker/Storage"
+)
+
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+//go:embed assets
+var assetsFS embed.FS
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"date": func(ms int64) string {
+		return time.UnixMilli(ms).Local().Format("02/01 15:04")
+	},
+	"duration": func(seconds int) string {
+		return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+	},
+	"queue": static.QueueName,
+}).ParseFS(templatesFS, "templates/*.html"))
+
+// Rank graph drawn as an SVG polyline
+type rankGraph struct {
+	Width, Height int
+	Points        string
+	Lines         []rankLine // one per tier crossed
+	Current       string
+}
+
+type rankLine struct {
+	Y     int
+	Label string
+}
+
+type playerRow struct {
+	Player *api.PlayerInfo
+	Rank   string
+}
+
+type leaderboardView struct {
+	Title   string
+	Entries []api.LeaderboardEntry
+}
+
+type indexPage struct {
+	Players      []playerRow
+	Leaderboards []leaderboardView
+}
+
+type playerPage struct {
+	Player  *api.PlayerInfo
+	Matches []api.MatchSummary
+	Graph   *rankGraph
+}
+
+type matchPage struct {
+	Player  *api.PlayerInfo
+	Summary api.MatchSummary
+	Worst   []api.StatSummary
+	Report  string
+}
+
+func registerDashboard(mux *http.ServeMux) {
+	assets, err := fs.Sub(assetsFS, "assets")
+	if err != nil {
+		log.Fatal("Error loading dashboard assets: " + err.Error())
+	}
+	mux.Handle("GET /assets/", http.StripPrefix("/assets/", http.FileServerFS(assets)))
+	mux.Handle("GET /{$}", requireDashboardAuth(http.HandlerFunc(getIndexPage)))
+	mux.Handle("GET /dashboard/players/{id}", requireDashboardAuth(http.HandlerFunc(getPlayerPage)))
+	mux.Handle("GET /dashboard/matches/{id}", requireDashboardAuth(http.HandlerFunc(getMatchPage)))
+	mux.Handle("GET /dashboard/matches/{id}/card.png", requireDashboardAuth(http.HandlerFunc(getMatchCard)))
+}
+
+func render(w http.ResponseWriter, name string, data any) {
+	renderStatus(w, http.StatusOK, name, data)
+}
+
+// Headers are only sent once the status is written, so they're set first
+func renderStatus(w http.ResponseWriter, status int, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := templates.ExecuteTemplate(w, name, data); err != nil {
+		log.Println("couldn't render " + name + ": " + err.Error())
+	}
+}
+
+func renderError(w http.ResponseWriter, status int, msg string) {
+	renderStatus(w, status, "error.html", msg)
+}
+
+func getIndexPage(w http.ResponseWriter, r *http.Request) {
+	page := indexPage{}
+	for _, p := range publicPlayers() {
+		row := playerRow{Player: p, Rank: "Unranked"}
+		if league, err := api.GetRankedStatsByPuuid(p.PUUID); err == nil {
+			row.Rank = league.String()
+		}
+		page.Players = append(page.Players, row)
+	}
+
+	filter, label, _ := api.ParsePeriod("week")
+	for _, stat := range []string{"winrate", "kda", "score"} {
+		entries, err := api.GetLeaderboard(publicPlayers(), stat, filter)
+		if err != nil {
+			continue
+		}
+		page.Leaderboards = append(page.Leaderboards, leaderboardView{api.GetLeaderboardTitle(stat, label), entries})
+	}
+	render(w, "index.html", page)
+}
+
+func getPlayerPage(w http.ResponseWriter, r *http.Request) {
+	player := findPlayer(r.PathValue("id"))
+	if player == nil {
+		renderError(w, http.StatusNotFound, "Joueur inconnu")
+		return
+	}
+	matches, err := api.GetPlayerMatchSummaries(player.PUUID, api.StatsFilter{}, 20)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	snapshots, err := storage.Store.LeagueSnapshots(player.PUUID)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	render(w, "player.html", playerPage{player, matches, newRankGraph(snapshots)})
+}
+
+func getMatchPage(w http.ResponseWriter, r *http.Request) {
+	matchID := r.PathValue("id")
+	match, err := storage.Store.GetMatch(matchID)
+	if err != nil {
+		renderError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if match == nil {
+		renderError(w, http.StatusNotFound, "Game non stockée")
+		return
+	}
+	player := matchPlayer(r, match)
+	if player == nil {
+		renderError(w, http.StatusNotFound, "Aucun joueur suivi dans cette game")
+		return
+	}
+
+	summary, ok := api.SummarizeMatch(match, player.PUUID)
+	if !ok {
+		renderError(w, http.StatusNotFound, "Le joueur n'est pas dans cette game")
+		return
+	}
+	worst, _ := api.GetWorstStats(match, player.PUUID)
+	report, err := api.GetMatchDescString(match, player)
+	if err != nil {
+		report = ""
+	}
+	render(w, "match.html", matchPage{player, summary, worst, report})
+}
+
+func getMatchCard(w http.ResponseWriter, r *http.Request) {
+	matchID := r.PathValue("id")
+	match, err := storage.Store.GetMatch(matchID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if match == nil {
+		http.Error(w, "match not stored", http.StatusNotFound)
+		return
+	}
+	player := matchPlayer(r, match)
+	if player == nil {
+		http.Error(w, "no tracked player in match", http.StatusNotFound)
+		return
+	}
+	png, err := card.Render(match, player.PUUID, api.DefaultReportOptions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(png)
+}
+
+// Player chosen with ?player=, or the first tracked player of the match,
+// among those who agreed to reports about it
+func matchPlayer(r *http.Request, match *api.Match) *api.PlayerInfo {
+	id := strings.TrimSpace(r.URL.Query().Get("player"))
+	for _, p := range api.AllowingInMatch(match, api.TrackedPlayers(), api.ReportAnnouncement) {
+		if id == "" || p.PUUID == id || strings.EqualFold(p.RiotID(), id) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Returns nil when there are less than 2 snapshots to draw
+func newRankGraph(snapshots []api.LeagueSnapshot) *rankGraph {
+	if len(snapshots) < 2 {
+		return nil
+	}
+	graph := &rankGraph{Width: 600, Height: 200}
+	const margin = 10
+
+	low, high := snapshots[0].RankValue(), snapshots[0].RankValue()
+	for _, s := range snapshots {
+		low = min(low, s.RankValue())
+		high = max(high, s.RankValue())
+	}
+	// Round to divisions so that the grid has lines
+	low = low / 100 * 100
+	high = (high/100 + 1) * 100
+	first, last := snapshots[0].Time, snapshots[len(snapshots)-1].Time
+
+	y := func(value int) int {
+		return graph.Height - margin - (value-low)*(graph.Height-2*margin)/(high-low)
+	}
+	points := make([]string, 0, len(snapshots))
+	for _, s := range snapshots {
+		x := margin
+		if last > first {
+			x += int(int64(graph.Width-2*margin) * (s.Time - first) / (last - first))
+		}
+		points = append(points, fmt.Sprintf("%d,%d", x, y(s.RankValue())))
+	}
+	graph.Points = strings.Join(points, " ")
+
+	for value := low; value <= high; value += 100 {
+		for _, s := range snapshots {
+			if s.RankValue()/100*100 == value {
+				graph.Lines = append(graph.Lines, rankLine{y(value), s.Tier + " " + s.Rank})
+				break
+			}
+		}
+	}
+	current := snapshots[len(snapshots)-1]
+	graph.Current = fmt.Sprintf("%s %s %d LP", current.Tier, current.Rank, current.LeaguePoints)
+	return graph
+}
@@ -0,0 +1,342 @@
+package server
+
+/* HTTP API exposing players, matches and stats as JSON */
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	api "github.com/Nvim/silverstalker/Api"
+	export "github.com/Nvim/silverstalker/Export"
+	storage "github.com/Nvim/silverstalker/Storage"
+)
+
+var (
+	ApiKey string = os.Getenv("HTTP_API_KEY")
+	Addr   string = os.Getenv("HTTP_ADDR") // server is disabled when empty
+	// Asked by the browser for the dashboard (any user name), the API key if empty
+	DashboardPassword string = os.Getenv("DASHBOARD_PASSWORD")
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type playerResponse struct {
+	ID       string `json:"id"` // PUUID
+	RiotID   string `json:"riotId"`
+	GameName string `json:"gameName"`
+	TagLine  string `json:"tagLine"`
+}
+
+type reportResponse struct {
+	MatchID    string            `json:"matchId"`
+	Player     string            `json:"player"`
+	Report     string            `json:"report"` // same text as the Discord message
+	WorstStats []api.StatSummary `json:"worstStats"`
+	Summary    api.MatchSummary  `json:"summary"`
+}
+
+type matchResponse struct {
+	MatchID      string   `json:"matchId"`
+	QueueID      int      `json:"queueId"`
+	GameCreation int64    `json:"gameCreation"` // epoch milliseconds
+	GameDuration int      `json:"gameDuration"` // seconds
+	Players      []string `json:"players"`      // Riot IDs of the tracked players who agreed to reports
+}
+
+// JSON endpoints need the API key, dashboard pages the dashboard password
+func NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /players", requireApiKey(http.HandlerFunc(getPlayers)))
+	mux.Handle("GET /players/{id}/matches", requireApiKey(http.HandlerFunc(getPlayerMatches)))
+	mux.Handle("GET /players/{id}/rank-history", requireApiKey(http.HandlerFunc(getRankHistory)))
+	mux.Handle("GET /matches", requireApiKey(http.HandlerFunc(getMatches)))
+	mux.Handle("GET /matches/{id}/report", requireApiKey(http.HandlerFunc(getMatchReport)))
+	mux.Handle("GET /export/{table}", requireApiKey(http.HandlerFunc(getExport)))
+	registerDashboard(mux)
+	return mux
+}
+
+// Serves the API on Addr until it fails
+func Listen() error {
+	if ApiKey == "" {
+		log.Println("HTTP_API_KEY is empty, every API request will be refused")
+	}
+	log.Println("HTTP server listening on " + Addr)
+	server := &http.Server{
+		Addr:              Addr,
+		Handler:           NewHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
+}
+
+// Accepts the key from the X-API-Key header or as a bearer token
+func requireApiKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if ApiKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(ApiKey)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Browsers can't send the API key header when following links, dashboard
+// pages use basic authentication instead
+func requireDashboardAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		password := DashboardPassword
+		if password == "" {
+			password = ApiKey
+		}
+		_, given, _ := r.BasicAuth()
+		if password == "" || subtle.ConstantTimeCompare([]byte(given), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="silverstalker", charset="UTF-8"`)
+			renderError(w, http.StatusUnauthorized, "Mot de passe invalide")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("couldn't write HTTP response: " + err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{msg})
+}
+
+// Tracked players who agreed to have their stats shown
+func publicPlayers() []*api.PlayerInfo {
+	return api.AllowingPlayers(api.TrackedPlayers(), api.ReportAnnouncement, false)
+}
+
+// Public player from a PUUID or a Riot ID ("Name#Tag", URL encoded)
+func findPlayer(id string) *api.PlayerInfo {
+	for _, p := range publicPlayers() {
+		if p.PUUID == id || strings.EqualFold(p.RiotID(), strings.TrimSpace(id)) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Filter from the queue, since and until query parameters (dates as YYYY-MM-DD)
+func parseFilter(r *http.Request) (api.StatsFilter, error) {
+	var filter api.StatsFilter
+	var err error
+	query := r.URL.Query()
+	if queue := query.Get("queue"); queue != "" {
+		if filter.Queue, err = strconv.Atoi(queue); err != nil {
+			return filter, err
+		}
+	}
+	if since := query.Get("since"); since != "" {
+		if filter.From, err = time.Parse(time.DateOnly, since); err != nil {
+			return filter, err
+		}
+	}
+	if until := query.Get("until"); until != "" {
+		if filter.To, err = api.ParseUntil(until); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+func getPlayers(w http.ResponseWriter, r *http.Request) {
+	public := publicPlayers()
+	players := make([]playerResponse, 0, len(public))
+	for _, p := range public {
+		players = append(players, playerResponse{p.PUUID, p.RiotID(), p.GameName, p.TagLine})
+	}
+	writeJSON(w, http.StatusOK, players)
+}
+
+func getPlayerMatches(w http.ResponseWriter, r *http.Request) {
+	player := findPlayer(r.PathValue("id"))
+	if player == nil {
+		writeError(w, http.StatusNotFound, "unknown player")
+		return
+	}
+	filter, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid filter: "+err.Error())
+		return
+	}
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	summaries, err := api.GetPlayerMatchSummaries(player.PUUID, filter, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// Games of the database, newest first, filtered like the player's matches
+func getMatches(w http.ResponseWriter, r *http.Request) {
+	filter := storage.MatchFilter{Limit: 20}
+	var err error
+	if filter.StatsFilter, err = parseFilter(r); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid filter: "+err.Error())
+		return
+	}
+	if id := r.URL.Query().Get("player"); id != "" {
+		player := findPlayer(id)
+		if player == nil {
+			writeError(w, http.StatusNotFound, "unknown player")
+			return
+		}
+		filter.PUUID = player.PUUID
+	}
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if filter.Limit, err = strconv.Atoi(l); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	matches, err := storage.Store.Matches(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response := make([]matchResponse, 0, len(matches))
+	for _, match := range matches {
+		players := make([]string, 0)
+		for _, p := range api.AllowingInMatch(match, api.TrackedPlayers(), api.ReportAnnouncement) {
+			players = append(players, p.RiotID())
+		}
+		if len(players) == 0 {
+			continue
+		}
+		info := match.Info
+		response = append(response, matchResponse{match.Metadata.MatchID, info.QueueID, info.GameCreation, info.GameDuration, players})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func getRankHistory(w http.ResponseWriter, r *http.Request) {
+	player := findPlayer(r.PathValue("id"))
+	if player == nil {
+		writeError(w, http.StatusNotFound, "unknown player")
+		return
+	}
+	snapshots, err := storage.Store.LeagueSnapshots(player.PUUID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if snapshots == nil {
+		snapshots = []api.LeagueSnapshot{}
+	}
+	writeJSON(w, http.StatusOK, snapshots)
+}
+
+// The report is about the player given in the "player" query parameter,
+// the first tracked player of the match otherwise, see matchPlayer
+func getMatchReport(w http.ResponseWriter, r *http.Request) {
+	matchID := r.PathValue("id")
+	match, err := storage.Store.GetMatch(matchID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if match == nil {
+		writeError(w, http.StatusNotFound, "match not stored")
+		return
+	}
+
+	player := matchPlayer(r, match)
+	if player == nil {
+		writeError(w, http.StatusNotFound, "no tracked player in match")
+		return
+	}
+
+	summary, ok := api.SummarizeMatch(match, player.PUUID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "player isn't in match")
+		return
+	}
+	report, err := api.GetMatchDescString(match, player)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	worst, err := api.GetWorstStats(match, player.PUUID)
+	if err != nil {
+		worst = []api.StatSummary{}
+	}
+	writeJSON(w, http.StatusOK, reportResponse{matchID, player.RiotID(), report, worst, summary})
+}
+
+// Table in the format query parameter (jsonl by default), filtered by the
+// player (repeatable), queue, since and until query parameters
+func getExport(w http.ResponseWriter, r *http.Request) {
+	table := r.PathValue("table")
+	if !slices.Contains(export.Tables, table) {
+		writeError(w, http.StatusNotFound, "unknown table")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "jsonl"
+	}
+	if !slices.Contains(export.Formats, format) {
+		writeError(w, http.StatusBadRequest, "unknown format")
+		return
+	}
+	statsFilter, err := parseFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid filter: "+err.Error())
+		return
+	}
+	filter := export.Filter{Players: publicPlayers(), StatsFilter: statsFilter}
+	if ids := r.URL.Query()["player"]; len(ids) > 0 {
+		filter.Players = make([]*api.PlayerInfo, 0, len(ids))
+		for _, id := range ids {
+			player := findPlayer(id)
+			if player == nil {
+				writeError(w, http.StatusNotFound, "unknown player: "+id)
+				return
+			}
+			filter.Players = append(filter.Players, player)
+		}
+	}
+
+	// Written to a buffer first so that errors can still be reported as JSON
+	var buf bytes.Buffer
+	if err := export.Export(&buf, table, format, filter); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", export.ContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="`+table+"."+format+`"`)
+	w.Write(buf.Bytes())
+}
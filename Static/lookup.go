@@ -0,0 +1,146 @@
+package static
+
+/* ID -> name/icon lookups used by the formatters. They never fail: unknown IDs
+ * and missing bundles fall back to a generic name. */
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Names in a Data Dragon locale, Locale if empty. The package functions use Locale.
+type Lang string
+
+func (l Lang) load(gameVersion string) (*Data, error) {
+	if l == "" {
+		return Load(gameVersion)
+	}
+	return LoadLocale(gameVersion, string(l))
+}
+
+func lookup(gameVersion string, get func(*Data) (Entry, bool)) (Entry, bool) {
+	return Lang("").lookup(gameVersion, get)
+}
+
+func (l Lang) lookup(gameVersion string, get func(*Data) (Entry, bool)) (Entry, bool) {
+	data, err := l.load(gameVersion)
+	if err != nil {
+		return Entry{}, false
+	}
+	return get(data)
+}
+
+func ChampionName(gameVersion string, id int) string {
+	return Lang("").ChampionName(gameVersion, id)
+}
+
+func (l Lang) ChampionName(gameVersion string, id int) string {
+	if c, ok := l.lookup(gameVersion, func(d *Data) (Entry, bool) { c, ok := d.Champions[id]; return c, ok }); ok {
+		return c.Name
+	}
+	return "Champion " + strconv.Itoa(id)
+}
+
+// Data Dragon identifier of the champion, as used in image file names
+func ChampionKey(gameVersion string, id int) (string, bool) {
+	c, ok := lookup(gameVersion, func(d *Data) (Entry, bool) { c, ok := d.Champions[id]; return c, ok })
+	return c.Key, ok
+}
+
+func ItemName(gameVersion string, id int) string {
+	return Lang("").ItemName(gameVersion, id)
+}
+
+func (l Lang) ItemName(gameVersion string, id int) string {
+	if i, ok := l.lookup(gameVersion, func(d *Data) (Entry, bool) { i, ok := d.Items[id]; return i.Entry, ok }); ok {
+		return i.Name
+	}
+	return "Objet " + strconv.Itoa(id)
+}
+
+func RuneName(gameVersion string, id int) string {
+	return Lang("").RuneName(gameVersion, id)
+}
+
+func (l Lang) RuneName(gameVersion string, id int) string {
+	if r, ok := l.lookup(gameVersion, func(d *Data) (Entry, bool) { r, ok := d.Runes[id]; return r, ok }); ok {
+		return r.Name
+	}
+	return "Rune " + strconv.Itoa(id)
+}
+
+func SummonerSpellName(gameVersion string, id int) string {
+	return Lang("").SummonerSpellName(gameVersion, id)
+}
+
+func (l Lang) SummonerSpellName(gameVersion string, id int) string {
+	if s, ok := l.lookup(gameVersion, func(d *Data) (Entry, bool) { s, ok := d.SummonerSpells[id]; return s, ok }); ok {
+		return s.Name
+	}
+	return "Sort " + strconv.Itoa(id)
+}
+
+func QueueName(id int) string {
+	if name, ok := loadQueues()[id]; ok {
+		return name
+	}
+	return "File " + strconv.Itoa(id)
+}
+
+func MapName(gameVersion string, id int) string {
+	if data, err := Load(gameVersion); err == nil {
+		if name, ok := data.Maps[id]; ok {
+			return name
+		}
+	}
+	return "Carte " + strconv.Itoa(id)
+}
+
+// Absolute path of the champion's icon, empty if the bundle doesn't have it
+func ChampionIcon(gameVersion string, id int) string {
+	c, ok := lookup(gameVersion, func(d *Data) (Entry, bool) { c, ok := d.Champions[id]; return c, ok })
+	if !ok || c.Icon == "" {
+		return ""
+	}
+	return filepath.Join(Dir, c.Icon)
+}
+
+// Absolute path of the champion's default splash art, empty if the bundle doesn't have it
+func ChampionSplash(gameVersion string, id int) string {
+	key, ok := ChampionKey(gameVersion, id)
+	if !ok {
+		return ""
+	}
+	path := filepath.Join(Dir, "img", "champion", "splash", key+"_0.jpg")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+func ItemIcon(gameVersion string, id int) string {
+	i, ok := lookup(gameVersion, func(d *Data) (Entry, bool) { i, ok := d.Items[id]; return i.Entry, ok })
+	if !ok || i.Icon == "" {
+		return ""
+	}
+	return filepath.Join(Dir, i.Icon)
+}
+
+// Whether the item has the given Data Dragon tag ("Boots", "Vision"...)
+func ItemHasTag(gameVersion string, id int, tag string) (hasTag bool, known bool) {
+	data, err := Load(gameVersion)
+	if err != nil {
+		return false, false
+	}
+	item, ok := data.Items[id]
+	if !ok {
+		return false, false
+	}
+	for _, t := range item.Tags {
+		if t == tag {
+			return true, true
+		}
+	}
+	return false, true
+}
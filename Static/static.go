@@ -0,0 +1,392 @@
+package static
+
+/* Static game data (champions, items, runes...) read from a local bundle of
+ * Data Dragon / Community Dragon files. Layout of the bundle directory:
+ *   <dir>/<version>/data/<locale>/{champion,item,summoner,runesReforged,map}.json  (Data Dragon)
+ *   <dir>/<version>/cdragon/{champion-summary,items}.json                          (Community Dragon fallback)
+ *   <dir>/<version>/img/...                                                        (icons)
+ *   <dir>/img/champion/splash/<Key>_0.jpg                                          (splash arts)
+ *   <dir>/queues.json                                                              (Riot static docs)
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Entry struct {
+	ID   int
+	Key  string // Data Dragon identifier, "MonkeyKing" for Wukong
+	Name string
+	Icon string // path of the icon file, relative to the bundle directory
+}
+
+type Item struct {
+	Entry
+	Tags []string
+}
+
+// Static data of one game version
+type Data struct {
+	Version        string
+	Champions      map[int]Entry
+	Items          map[int]Item
+	Runes          map[int]Entry // keystones, runes and trees
+	SummonerSpells map[int]Entry
+	Maps           map[int]string
+}
+
+var (
+	Dir         = bundleDir()
+	Locale      = bundleLocale()
+	loaded      = make(map[string]*Data) // keyed by bundle version and locale
+	loadErrors  = make(map[string]error) // bundles that failed to load, not retried
+	queues      map[int]string
+	loadMu      sync.Mutex
+	versions    []string // read once, the bundle is updated between runs
+	versionsMu  sync.Mutex
+	ErrNoBundle = errors.New("no static data bundle found")
+)
+
+func bundleDir() string {
+	if dir := os.Getenv("STATIC_DIR"); dir != "" {
+		return dir
+	}
+	return "static"
+}
+
+func bundleLocale() string {
+	if locale := os.Getenv("STATIC_LOCALE"); locale != "" {
+		return locale
+	}
+	return "fr_FR"
+}
+
+// "14.15.604.8769" -> [14 15 604 8769]
+func parseVersion(version string) []int {
+	parts := strings.Split(version, ".")
+	nums := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		nums = append(nums, n)
+	}
+	return nums
+}
+
+// Versions available in the bundle, newest first
+func Versions() []string {
+	versionsMu.Lock()
+	defer versionsMu.Unlock()
+	if versions != nil {
+		return versions
+	}
+
+	versions = make([]string, 0)
+	dirs, err := os.ReadDir(Dir)
+	if err != nil {
+		return versions
+	}
+	for _, d := range dirs {
+		if d.IsDir() && len(parseVersion(d.Name())) >= 2 {
+			versions = append(versions, d.Name())
+		}
+	}
+	slices.SortFunc(versions, func(a, b string) int {
+		return slices.Compare(parseVersion(b), parseVersion(a))
+	})
+	return versions
+}
+
+// Data Dragon locales of the newest bundle version
+func Locales() []string {
+	versions := Versions()
+	if len(versions) == 0 {
+		return nil
+	}
+	dirs, err := os.ReadDir(filepath.Join(Dir, versions[0], "data"))
+	if err != nil {
+		return nil
+	}
+	locales := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		if d.IsDir() {
+			locales = append(locales, d.Name())
+		}
+	}
+	return locales
+}
+
+// Picks the bundle version matching the game version's patch, or the newest
+// one released before it. An empty game version picks the newest bundle.
+func resolveVersion(gameVersion string) (string, error) {
+	versions := Versions()
+	if len(versions) == 0 {
+		return "", ErrNoBundle
+	}
+	game := parseVersion(gameVersion)
+	if len(game) < 2 {
+		return versions[0], nil
+	}
+	for _, v := range versions {
+		if slices.Compare(parseVersion(v)[:2], game[:2]) <= 0 {
+			return v, nil
+		}
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Static data for the game version in the default locale, see resolveVersion
+func Load(gameVersion string) (*Data, error) {
+	return LoadLocale(gameVersion, Locale)
+}
+
+// Static data for the game version with names in the given Data Dragon
+// locale ("en_US", "fr_FR"...)
+func LoadLocale(gameVersion string, locale string) (*Data, error) {
+	version, err := resolveVersion(gameVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	key := version + "/" + locale
+	if data, ok := loaded[key]; ok {
+		return data, nil
+	}
+	if err, ok := loadErrors[key]; ok {
+		return nil, err
+	}
+	data, err := loadVersion(version, locale)
+	if err != nil {
+		loadErrors[key] = err
+		return nil, err
+	}
+	loaded[key] = data
+	return data, nil
+}
+
+func readJSON(path string, v any) error {
+	raw, err := os.ReadFile(filepath.Join(Dir, path))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func loadVersion(version string, locale string) (*Data, error) {
+	data := &Data{
+		Version:        version,
+		Champions:      make(map[int]Entry),
+		Items:          make(map[int]Item),
+		Runes:          make(map[int]Entry),
+		SummonerSpells: make(map[int]Entry),
+		Maps:           make(map[int]string),
+	}
+	ddragon := filepath.Join(version, "data", locale)
+
+	if err := loadChampions(data, ddragon); err != nil {
+		if err := loadCDragonChampions(data, version); err != nil {
+			return nil, err
+		}
+	}
+	if err := loadItems(data, ddragon); err != nil {
+		if err := loadCDragonItems(data, version); err != nil {
+			return nil, err
+		}
+	}
+	if err := loadSummonerSpells(data, ddragon); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := loadRunes(data, ddragon); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err := loadMaps(data, ddragon); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return data, nil
+}
+
+type ddragonImage struct {
+	Full string `json:"full"`
+}
+
+func loadChampions(data *Data, ddragon string) error {
+	var file struct {
+		Data map[string]struct {
+			ID    string       `json:"id"`
+			Key   string       `json:"key"` // numeric ID, as a string
+			Name  string       `json:"name"`
+			Image ddragonImage `json:"image"`
+		} `json:"data"`
+	}
+	if err := readJSON(filepath.Join(ddragon, "champion.json"), &file); err != nil {
+		return err
+	}
+	for _, c := range file.Data {
+		id, err := strconv.Atoi(c.Key)
+		if err != nil {
+			continue
+		}
+		data.Champions[id] = Entry{id, c.ID, c.Name, filepath.Join(data.Version, "img", "champion", c.Image.Full)}
+	}
+	return nil
+}
+
+func loadCDragonChampions(data *Data, version string) error {
+	var file []struct {
+		ID                 int    `json:"id"`
+		Name               string `json:"name"`
+		Alias              string `json:"alias"`
+		SquarePortraitPath string `json:"squarePortraitPath"`
+	}
+	if err := readJSON(filepath.Join(version, "cdragon", "champion-summary.json"), &file); err != nil {
+		return err
+	}
+	for _, c := range file {
+		data.Champions[c.ID] = Entry{c.ID, c.Alias, c.Name, filepath.Join(version, "cdragon", filepath.Base(c.SquarePortraitPath))}
+	}
+	return nil
+}
+
+func loadItems(data *Data, ddragon string) error {
+	var file struct {
+		Data map[string]struct {
+			Name  string       `json:"name"`
+			Tags  []string     `json:"tags"`
+			Image ddragonImage `json:"image"`
+		} `json:"data"`
+	}
+	if err := readJSON(filepath.Join(ddragon, "item.json"), &file); err != nil {
+		return err
+	}
+	for key, i := range file.Data {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		data.Items[id] = Item{Entry{id, key, i.Name, filepath.Join(data.Version, "img", "item", i.Image.Full)}, i.Tags}
+	}
+	return nil
+}
+
+func loadCDragonItems(data *Data, version string) error {
+	var file []struct {
+		ID         int      `json:"id"`
+		Name       string   `json:"name"`
+		Categories []string `json:"categories"`
+		IconPath   string   `json:"iconPath"`
+	}
+	if err := readJSON(filepath.Join(version, "cdragon", "items.json"), &file); err != nil {
+		return err
+	}
+	for _, i := range file {
+		data.Items[i.ID] = Item{Entry{i.ID, strconv.Itoa(i.ID), i.Name, filepath.Join(version, "cdragon", filepath.Base(i.IconPath))}, i.Categories}
+	}
+	return nil
+}
+
+func loadSummonerSpells(data *Data, ddragon string) error {
+	var file struct {
+		Data map[string]struct {
+			ID    string       `json:"id"`
+			Key   string       `json:"key"`
+			Name  string       `json:"name"`
+			Image ddragonImage `json:"image"`
+		} `json:"data"`
+	}
+	if err := readJSON(filepath.Join(ddragon, "summoner.json"), &file); err != nil {
+		return err
+	}
+	for _, s := range file.Data {
+		id, err := strconv.Atoi(s.Key)
+		if err != nil {
+			continue
+		}
+		data.SummonerSpells[id] = Entry{id, s.ID, s.Name, filepath.Join(data.Version, "img", "spell", s.Image.Full)}
+	}
+	return nil
+}
+
+type ddragonRune struct {
+	ID   int    `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Icon string `json:"icon"`
+}
+
+func loadRunes(data *Data, ddragon string) error {
+	var file []struct {
+		ddragonRune
+		Slots []struct {
+			Runes []ddragonRune `json:"runes"`
+		} `json:"slots"`
+	}
+	if err := readJSON(filepath.Join(ddragon, "runesReforged.json"), &file); err != nil {
+		return err
+	}
+	for _, tree := range file {
+		data.Runes[tree.ID] = Entry{tree.ID, tree.Key, tree.Name, filepath.Join("img", tree.Icon)}
+		for _, slot := range tree.Slots {
+			for _, r := range slot.Runes {
+				data.Runes[r.ID] = Entry{r.ID, r.Key, r.Name, filepath.Join("img", r.Icon)}
+			}
+		}
+	}
+	return nil
+}
+
+func loadMaps(data *Data, ddragon string) error {
+	var file struct {
+		Data map[string]struct {
+			MapName string `json:"MapName"`
+			MapID   string `json:"MapId"`
+		} `json:"data"`
+	}
+	if err := readJSON(filepath.Join(ddragon, "map.json"), &file); err != nil {
+		return err
+	}
+	for _, m := range file.Data {
+		id, err := strconv.Atoi(m.MapID)
+		if err != nil {
+			continue
+		}
+		data.Maps[id] = m.MapName
+	}
+	return nil
+}
+
+func loadQueues() map[int]string {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	if queues != nil {
+		return queues
+	}
+
+	queues = make(map[int]string)
+	var file []struct {
+		QueueID     int    `json:"queueId"`
+		Map         string `json:"map"`
+		Description string `json:"description"`
+	}
+	if err := readJSON("queues.json", &file); err != nil {
+		return queues
+	}
+	for _, q := range file {
+		name := strings.TrimSuffix(strings.TrimSpace(q.Description), " games")
+		if name == "" {
+			name = q.Map
+		}
+		queues[q.QueueID] = name
+	}
+	return queues
+}
@@ -0,0 +1,64 @@
+package storage
+
+/* Import of the files written before the database, and by the commands that
+ * don't open it */
+
+import (
+	"log"
+
+	api "github.com/Nvim/silverstalker/Api"
+)
+
+// Stores the games of the player's history file that the repository misses
+func ImportMatches(repo Repository, puuid string) error {
+	history, err := api.LoadMatchHistory(puuid)
+	if err != nil {
+		return err
+	}
+	ids, err := repo.MatchIDs(puuid)
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		stored[id] = true
+	}
+	imported := 0
+	for _, id := range history.MatchIDs {
+		if stored[id] {
+			continue
+		}
+		raw, ok := api.ResponseCache.Get("match/" + id)
+		if !ok {
+			continue
+		}
+		if err := repo.SaveMatch(raw); err != nil {
+			return err
+		}
+		imported++
+	}
+	if imported > 0 {
+		log.Printf("Imported %d games of %s\n", imported, puuid)
+	}
+	return nil
+}
+
+// Imports the history and rank snapshot files of tracked players, whose
+// accounts must be stored first
+func ImportFiles(repo Repository, players []*api.PlayerInfo) error {
+	for _, p := range players {
+		if err := ImportMatches(repo, p.PUUID); err != nil {
+			return err
+		}
+		snapshots, err := api.LoadLeagueSnapshotsFile(p.PUUID)
+		if err != nil {
+			return err
+		}
+		for _, s := range snapshots {
+			if err := repo.SaveLeagueSnapshot(p.PUUID, s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
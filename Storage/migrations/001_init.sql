@@ -0,0 +1,84 @@
+-- Portable between SQLite and PostgreSQL
+
+CREATE TABLE players (
+	id TEXT PRIMARY KEY, -- Discord user ID
+	name TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE accounts (
+	puuid TEXT PRIMARY KEY,
+	player_id TEXT REFERENCES players (id) ON DELETE SET NULL,
+	game_name TEXT NOT NULL,
+	tag_line TEXT NOT NULL,
+	summoner_id TEXT NOT NULL DEFAULT '',
+	account_id TEXT NOT NULL DEFAULT '',
+	tracked BOOLEAN NOT NULL DEFAULT TRUE
+);
+CREATE INDEX accounts_player_id ON accounts (player_id);
+
+CREATE TABLE matches (
+	match_id TEXT PRIMARY KEY,
+	queue_id INTEGER NOT NULL,
+	game_creation BIGINT NOT NULL, -- epoch milliseconds
+	game_duration INTEGER NOT NULL, -- seconds
+	game_version TEXT NOT NULL,
+	raw TEXT NOT NULL -- match-v5 JSON as returned by the Riot API
+);
+CREATE INDEX matches_game_creation ON matches (game_creation);
+CREATE INDEX matches_queue_id ON matches (queue_id);
+
+CREATE TABLE participants (
+	match_id TEXT NOT NULL REFERENCES matches (match_id) ON DELETE CASCADE,
+	puuid TEXT NOT NULL,
+	team_id INTEGER NOT NULL,
+	champion TEXT NOT NULL,
+	position TEXT NOT NULL,
+	win BOOLEAN NOT NULL,
+	kills INTEGER NOT NULL,
+	deaths INTEGER NOT NULL,
+	assists INTEGER NOT NULL,
+	cs INTEGER NOT NULL,
+	damage INTEGER NOT NULL,
+	gold INTEGER NOT NULL,
+	vision_score INTEGER NOT NULL,
+	PRIMARY KEY (match_id, puuid)
+);
+CREATE INDEX participants_puuid ON participants (puuid);
+
+CREATE TABLE league_snapshots (
+	puuid TEXT NOT NULL REFERENCES accounts (puuid) ON DELETE CASCADE,
+	time BIGINT NOT NULL, -- epoch seconds
+	tier TEXT NOT NULL,
+	division TEXT NOT NULL,
+	league_points INTEGER NOT NULL,
+	wins INTEGER NOT NULL,
+	losses INTEGER NOT NULL,
+	PRIMARY KEY (puuid, time)
+);
+
+CREATE TABLE announcements (
+	match_id TEXT NOT NULL,
+	destination TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	message_id TEXT NOT NULL,
+	time BIGINT NOT NULL,
+	PRIMARY KEY (match_id, destination, kind)
+);
+
+CREATE TABLE guild_settings (
+	guild_id TEXT PRIMARY KEY,
+	channel_id TEXT NOT NULL DEFAULT '',
+	template TEXT NOT NULL,
+	roast INTEGER NOT NULL,
+	locale TEXT NOT NULL DEFAULT '',
+	scoreboard BOOLEAN NOT NULL DEFAULT FALSE,
+	card BOOLEAN NOT NULL DEFAULT FALSE,
+	admin_role TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE guild_players (
+	guild_id TEXT NOT NULL REFERENCES guild_settings (guild_id) ON DELETE CASCADE,
+	puuid TEXT NOT NULL,
+	position INTEGER NOT NULL, -- order the guild started tracking them in
+	PRIMARY KEY (guild_id, puuid)
+);
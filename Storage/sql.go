@@ -0,0 +1,392 @@
+package storage
+
+/* Repository backed by SQLite or PostgreSQL. Queries stick to the SQL both
+understand, placeholders are numbered ($1) for both of them. */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	api "github.com/Nvim/silverstalker/Api"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+type SQL struct {
+	db *sql.DB
+}
+
+func databaseURL() string {
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return url
+	}
+	return "silverstalker.db"
+}
+
+// Opens the database and migrates it. URLs starting with postgres:// or
+// postgresql:// are PostgreSQL databases, anything else is a SQLite file.
+func Open(url string) (*SQL, error) {
+	postgres := strings.HasPrefix(url, "postgres://") || strings.HasPrefix(url, "postgresql://")
+	if !postgres && !sqliteSupported {
+		return nil, errors.New("SQLite needs a build with cgo (CGO_ENABLED=1 and a C compiler), use a postgres:// DATABASE_URL otherwise")
+	}
+	var db *sql.DB
+	var err error
+	if postgres {
+		db, err = sql.Open("pgx", url)
+	} else {
+		db, err = sql.Open("sqlite3", "file:"+url+"?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !postgres {
+		// Writes are serialized by SQLite anyway, this avoids "database is locked"
+		db.SetMaxOpenConns(1)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQL{db}, nil
+}
+
+func (s *SQL) Close() error {
+	return s.db.Close()
+}
+
+// Runs fn in a transaction, rolled back if it fails
+func (s *SQL) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Empty strings are stored as NULL in nullable columns
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (s *SQL) SavePlayer(p Player) error {
+	_, err := s.db.Exec(`INSERT INTO players (id, name) VALUES ($1, $2)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name`, p.ID, p.Name)
+	return err
+}
+
+func (s *SQL) DeletePlayer(id string) error {
+	_, err := s.db.Exec("DELETE FROM players WHERE id = $1", id)
+	return err
+}
+
+func (s *SQL) Players() ([]Player, error) {
+	rows, err := s.db.Query("SELECT id, name FROM players ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	players := make([]Player, 0)
+	for rows.Next() {
+		var p Player
+		if err := rows.Scan(&p.ID, &p.Name); err != nil {
+			return nil, err
+		}
+		players = append(players, p)
+	}
+	return players, rows.Err()
+}
+
+func (s *SQL) SaveAccount(a Account) error {
+	_, err := s.db.Exec(`INSERT INTO accounts (puuid, player_id, game_name, tag_line, summoner_id, account_id, tracked)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (puuid) DO UPDATE SET player_id = excluded.player_id, game_name = excluded.game_name,
+			tag_line = excluded.tag_line, summoner_id = excluded.summoner_id,
+			account_id = excluded.account_id, tracked = excluded.tracked`,
+		a.PUUID, nullString(a.PlayerID), a.GameName, a.TagLine, a.SummonerID, a.AccountID, a.Tracked)
+	return err
+}
+
+func (s *SQL) LinkAccount(a Account) error {
+	_, err := s.db.Exec(`INSERT INTO accounts (puuid, player_id, game_name, tag_line, summoner_id, account_id, tracked)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (puuid) DO UPDATE SET player_id = excluded.player_id`,
+		a.PUUID, nullString(a.PlayerID), a.GameName, a.TagLine, a.SummonerID, a.AccountID, a.Tracked)
+	return err
+}
+
+func (s *SQL) UnlinkAccount(puuid string) error {
+	_, err := s.db.Exec("UPDATE accounts SET player_id = NULL WHERE puuid = $1", puuid)
+	return err
+}
+
+func (s *SQL) DeleteAccount(puuid string) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`DELETE FROM matches WHERE match_id IN (
+				SELECT match_id FROM participants WHERE puuid = $1
+			) AND match_id NOT IN (
+				SELECT p.match_id FROM participants p JOIN accounts a ON a.puuid = p.puuid
+				WHERE a.tracked AND a.puuid <> $1
+			)`, puuid)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("DELETE FROM accounts WHERE puuid = $1", puuid)
+		return err
+	})
+}
+
+func (s *SQL) Accounts() ([]Account, error) {
+	rows, err := s.db.Query(`SELECT puuid, player_id, game_name, tag_line, summoner_id, account_id, tracked
+		FROM accounts ORDER BY game_name, tag_line`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	accounts := make([]Account, 0)
+	for rows.Next() {
+		var a Account
+		var playerID sql.NullString
+		if err := rows.Scan(&a.PUUID, &playerID, &a.GameName, &a.TagLine, &a.SummonerID, &a.AccountID, &a.Tracked); err != nil {
+			return nil, err
+		}
+		a.PlayerID = playerID.String
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+func (s *SQL) SaveMatch(raw []byte) error {
+	var match api.Match
+	if err := json.Unmarshal(raw, &match); err != nil {
+		return api.ErrJson
+	}
+	if match.Metadata.MatchID == "" {
+		return errors.New("match has no ID")
+	}
+	info := match.Info
+	return s.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO matches (match_id, queue_id, game_creation, game_duration, game_version, raw)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (match_id) DO UPDATE SET raw = excluded.raw`,
+			match.Metadata.MatchID, info.QueueID, info.GameCreation, info.GameDuration, info.GameVersion, string(raw))
+		if err != nil {
+			return err
+		}
+		for _, p := range info.Participants {
+			_, err := tx.Exec(`INSERT INTO participants (match_id, puuid, team_id, champion, position, win,
+					kills, deaths, assists, cs, damage, gold, vision_score)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+				ON CONFLICT (match_id, puuid) DO NOTHING`,
+				match.Metadata.MatchID, p.Puuid, p.TeamId, p.ChampionName, p.TeamPosition, p.Win,
+				p.Kills, p.Deaths, p.Assists, p.TotalMinionsKilled+p.NeutralMinionsKilled,
+				p.TotalDamageDealtToChampions, p.GoldEarned, p.VisionScore)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func decodeMatch(raw string) (*api.Match, error) {
+	var match api.Match
+	if err := json.Unmarshal([]byte(raw), &match); err != nil {
+		return nil, api.ErrJson
+	}
+	return &match, nil
+}
+
+func (s *SQL) GetMatch(id string) (*api.Match, error) {
+	var raw string
+	err := s.db.QueryRow("SELECT raw FROM matches WHERE match_id = $1", id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeMatch(raw)
+}
+
+func (s *SQL) Matches(filter MatchFilter) ([]*api.Match, error) {
+	// Unset filters are matched by their zero value. Timestamps are cast since
+	// PostgreSQL would otherwise type them after the 0 they're compared to.
+	var from, to int64
+	if !filter.From.IsZero() {
+		from = filter.From.UnixMilli()
+	}
+	if !filter.To.IsZero() {
+		to = filter.To.UnixMilli()
+	}
+	query := `SELECT m.raw FROM matches m
+		WHERE ($1 = '' OR EXISTS (SELECT 1 FROM participants p WHERE p.match_id = m.match_id AND p.puuid = $1))
+		AND ($2 = 0 OR m.queue_id = $2)
+		AND (CAST($3 AS BIGINT) = 0 OR m.game_creation >= CAST($3 AS BIGINT))
+		AND (CAST($4 AS BIGINT) = 0 OR m.game_creation < CAST($4 AS BIGINT))
+		ORDER BY m.game_creation DESC`
+	args := []any{filter.PUUID, filter.Queue, from, to}
+	if filter.Limit > 0 {
+		query += " LIMIT $5"
+		args = append(args, filter.Limit)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	matches := make([]*api.Match, 0)
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		match, err := decodeMatch(raw)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
+
+func (s *SQL) MatchIDs(puuid string) ([]string, error) {
+	rows, err := s.db.Query("SELECT match_id FROM participants WHERE puuid = $1", puuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (s *SQL) SaveLeagueSnapshot(puuid string, snapshot api.LeagueSnapshot) error {
+	_, err := s.db.Exec(`INSERT INTO league_snapshots (puuid, time, tier, division, league_points, wins, losses)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (puuid, time) DO NOTHING`,
+		puuid, snapshot.Time, snapshot.Tier, snapshot.Rank, snapshot.LeaguePoints, snapshot.Wins, snapshot.Losses)
+	return err
+}
+
+func (s *SQL) LeagueSnapshots(puuid string) ([]api.LeagueSnapshot, error) {
+	rows, err := s.db.Query(`SELECT time, tier, division, league_points, wins, losses
+		FROM league_snapshots WHERE puuid = $1 ORDER BY time`, puuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	snapshots := make([]api.LeagueSnapshot, 0)
+	for rows.Next() {
+		var snapshot api.LeagueSnapshot
+		if err := rows.Scan(&snapshot.Time, &snapshot.Tier, &snapshot.Rank, &snapshot.LeaguePoints, &snapshot.Wins, &snapshot.Losses); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+func (s *SQL) SaveAnnouncement(a Announcement) error {
+	if a.Time == 0 {
+		a.Time = time.Now().Unix()
+	}
+	_, err := s.db.Exec(`INSERT INTO announcements (match_id, destination, kind, message_id, time)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (match_id, destination, kind) DO UPDATE SET message_id = excluded.message_id, time = excluded.time`,
+		a.MatchID, a.Destination, a.Kind, a.MessageID, a.Time)
+	return err
+}
+
+func (s *SQL) Announcements(matchID string) ([]Announcement, error) {
+	rows, err := s.db.Query(`SELECT match_id, destination, kind, message_id, time
+		FROM announcements WHERE match_id = $1 ORDER BY time`, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	announcements := make([]Announcement, 0)
+	for rows.Next() {
+		var a Announcement
+		if err := rows.Scan(&a.MatchID, &a.Destination, &a.Kind, &a.MessageID, &a.Time); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, a)
+	}
+	return announcements, rows.Err()
+}
+
+func (s *SQL) SaveGuild(g GuildSettings) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO guild_settings (guild_id, channel_id, template, roast, locale, scoreboard, card, admin_role)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (guild_id) DO UPDATE SET channel_id = excluded.channel_id, template = excluded.template,
+				roast = excluded.roast, locale = excluded.locale, scoreboard = excluded.scoreboard,
+				card = excluded.card, admin_role = excluded.admin_role`,
+			g.GuildID, g.ChannelID, g.Template, g.Roast, g.Locale, g.Scoreboard, g.Card, g.AdminRole)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM guild_players WHERE guild_id = $1", g.GuildID); err != nil {
+			return err
+		}
+		for i, puuid := range g.Players {
+			_, err := tx.Exec("INSERT INTO guild_players (guild_id, puuid, position) VALUES ($1, $2, $3)", g.GuildID, puuid, i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *SQL) Guilds() ([]GuildSettings, error) {
+	rows, err := s.db.Query(`SELECT guild_id, channel_id, template, roast, locale, scoreboard, card, admin_role
+		FROM guild_settings ORDER BY guild_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	guilds := make([]GuildSettings, 0)
+	byID := make(map[string]int)
+	for rows.Next() {
+		g := GuildSettings{Players: []string{}}
+		if err := rows.Scan(&g.GuildID, &g.ChannelID, &g.Template, &g.Roast, &g.Locale, &g.Scoreboard, &g.Card, &g.AdminRole); err != nil {
+			return nil, err
+		}
+		byID[g.GuildID] = len(guilds)
+		guilds = append(guilds, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	players, err := s.db.Query("SELECT guild_id, puuid FROM guild_players ORDER BY guild_id, position")
+	if err != nil {
+		return nil, err
+	}
+	defer players.Close()
+	for players.Next() {
+		var guildID, puuid string
+		if err := players.Scan(&guildID, &puuid); err != nil {
+			return nil, err
+		}
+		if i, ok := byID[guildID]; ok {
+			guilds[i].Players = append(guilds[i].Players, puuid)
+		}
+	}
+	return guilds, players.Err()
+}
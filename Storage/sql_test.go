@@ -0,0 +1,320 @@
+//go:build cgo
+
+package storage
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	api "github.com/Nvim/silverstalker/Api"
+)
+
+func openTemp(t *testing.T) *SQL {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// Raw match-v5 JSON of a game between the accounts, blue side first
+func rawMatch(t *testing.T, id string, queue int, creation time.Time, blue []string, red []string) []byte {
+	t.Helper()
+	match := api.Match{
+		Metadata: api.MatchMetadata{MatchID: id},
+		Info: api.MatchInfo{
+			QueueID:      queue,
+			GameCreation: creation.UnixMilli(),
+			GameDuration: 1800,
+			GameVersion:  "14.15.604.8769",
+		},
+	}
+	for team, puuids := range map[int][]string{100: blue, 200: red} {
+		for _, puuid := range puuids {
+			match.Info.Participants = append(match.Info.Participants, api.Participant{
+				Puuid: puuid, TeamId: team, ChampionName: "Ahri", Win: team == 100, Kills: 3,
+			})
+		}
+	}
+	raw, err := json.Marshal(match)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func matchIDs(matches []*api.Match) []string {
+	ids := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.Metadata.MatchID)
+	}
+	return ids
+}
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	for range 2 {
+		// Reopening applies nothing twice
+		store, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		migrations, err := loadMigrations()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var count, version int
+		err = store.db.QueryRow("SELECT COUNT(*), MAX(version) FROM schema_migrations").Scan(&count, &version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != len(migrations) || version != migrations[len(migrations)-1].version {
+			t.Errorf("%d migrations applied up to %d, want %d", count, version, len(migrations))
+		}
+		store.Close()
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %s has version %d, want %d", m.name, m.version, i+1)
+		}
+	}
+}
+
+func TestAccounts(t *testing.T) {
+	store := openTemp(t)
+	if err := store.SavePlayer(Player{ID: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	tracked := Account{PUUID: "a", GameName: "A", TagLine: "EUW", Tracked: true}
+	if err := store.SaveAccount(tracked); err != nil {
+		t.Fatal(err)
+	}
+
+	// Linking keeps the stored details, unknown accounts are stored untracked
+	if err := store.LinkAccount(Account{PUUID: "a", PlayerID: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.LinkAccount(Account{PUUID: "b", PlayerID: "user", GameName: "B", TagLine: "EUW"}); err != nil {
+		t.Fatal(err)
+	}
+	tracked.PlayerID = "user"
+	want := []Account{tracked, {PUUID: "b", PlayerID: "user", GameName: "B", TagLine: "EUW"}}
+	if accounts, err := store.Accounts(); err != nil || !reflect.DeepEqual(accounts, want) {
+		t.Errorf("accounts = %+v, %v, want %+v", accounts, err, want)
+	}
+
+	if err := store.UnlinkAccount("b"); err != nil {
+		t.Fatal(err)
+	}
+	want[1].PlayerID = ""
+	if accounts, err := store.Accounts(); err != nil || !reflect.DeepEqual(accounts, want) {
+		t.Errorf("after unlink: %+v, %v, want %+v", accounts, err, want)
+	}
+
+	// Accounts of deleted players are unlinked
+	if err := store.DeletePlayer("user"); err != nil {
+		t.Fatal(err)
+	}
+	want[0].PlayerID = ""
+	if accounts, err := store.Accounts(); err != nil || !reflect.DeepEqual(accounts, want) {
+		t.Errorf("after player deletion: %+v, %v, want %+v", accounts, err, want)
+	}
+	if players, err := store.Players(); err != nil || len(players) != 0 {
+		t.Errorf("players = %v, %v, want none", players, err)
+	}
+}
+
+func TestSyncAccounts(t *testing.T) {
+	store := openTemp(t)
+	if err := store.SavePlayer(Player{ID: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveAccount(Account{PUUID: "old", GameName: "Old", TagLine: "EUW", Tracked: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.LinkAccount(Account{PUUID: "a", PlayerID: "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	players := []*api.PlayerInfo{{PUUID: "a", GameName: "A", TagLine: "EUW"}}
+	if err := SyncAccounts(store, players); err != nil {
+		t.Fatal(err)
+	}
+	want := []Account{
+		{PUUID: "a", PlayerID: "user", GameName: "A", TagLine: "EUW", Tracked: true},
+		{PUUID: "old", GameName: "Old", TagLine: "EUW"},
+	}
+	if accounts, err := store.Accounts(); err != nil || !reflect.DeepEqual(accounts, want) {
+		t.Errorf("accounts = %+v, %v, want %+v", accounts, err, want)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	store := openTemp(t)
+	day := time.Date(2024, time.June, 1, 20, 0, 0, 0, time.UTC)
+	games := [][]byte{
+		rawMatch(t, "EUW1_1", 420, day, []string{"a", "b"}, []string{"x"}),
+		rawMatch(t, "EUW1_2", 440, day.AddDate(0, 0, 1), []string{"x"}, []string{"a"}),
+		rawMatch(t, "EUW1_3", 420, day.AddDate(0, 0, 2), []string{"b"}, []string{"y"}),
+	}
+	for _, raw := range games {
+		if err := store.SaveMatch(raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Saving again updates the game
+	if err := store.SaveMatch(games[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter MatchFilter
+		want   []string
+	}{
+		{"all", MatchFilter{}, []string{"EUW1_3", "EUW1_2", "EUW1_1"}},
+		{"account", MatchFilter{PUUID: "a"}, []string{"EUW1_2", "EUW1_1"}},
+		{"queue", MatchFilter{StatsFilter: api.StatsFilter{Queue: 420}}, []string{"EUW1_3", "EUW1_1"}},
+		{"from", MatchFilter{StatsFilter: api.StatsFilter{From: day.AddDate(0, 0, 1)}}, []string{"EUW1_3", "EUW1_2"}},
+		{"to is exclusive", MatchFilter{StatsFilter: api.StatsFilter{To: day.AddDate(0, 0, 1)}}, []string{"EUW1_1"}},
+		{"limit", MatchFilter{PUUID: "b", Limit: 1}, []string{"EUW1_3"}},
+	}
+	for _, test := range tests {
+		matches, err := store.Matches(test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := matchIDs(matches); !slices.Equal(ids, test.want) {
+			t.Errorf("%s: %v, want %v", test.name, ids, test.want)
+		}
+	}
+
+	match, err := store.GetMatch("EUW1_2")
+	if err != nil || match == nil || len(match.Info.Participants) != 2 || match.Info.QueueID != 440 {
+		t.Errorf("GetMatch = %+v, %v", match, err)
+	}
+	if match, err := store.GetMatch("EUW1_404"); match != nil || err != nil {
+		t.Errorf("GetMatch of an unknown game = %+v, %v", match, err)
+	}
+	ids, err := store.MatchIDs("a")
+	slices.Sort(ids)
+	if err != nil || !slices.Equal(ids, []string{"EUW1_1", "EUW1_2"}) {
+		t.Errorf("MatchIDs = %v, %v", ids, err)
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	store := openTemp(t)
+	for _, puuid := range []string{"a", "b"} {
+		if err := store.SaveAccount(Account{PUUID: puuid, Tracked: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	day := time.Date(2024, time.June, 1, 20, 0, 0, 0, time.UTC)
+	for _, raw := range [][]byte{
+		rawMatch(t, "EUW1_1", 420, day, []string{"a", "b"}, nil),
+		rawMatch(t, "EUW1_2", 420, day, []string{"a"}, []string{"x"}),
+	} {
+		if err := store.SaveMatch(raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SaveLeagueSnapshot("a", api.LeagueSnapshot{Time: 1, Tier: "GOLD", Rank: "I"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteAccount("a"); err != nil {
+		t.Fatal(err)
+	}
+	// The game shared with another tracked account stays
+	matches, err := store.Matches(MatchFilter{})
+	if ids := matchIDs(matches); err != nil || !slices.Equal(ids, []string{"EUW1_1"}) {
+		t.Errorf("games = %v, %v, want EUW1_1", ids, err)
+	}
+	if snapshots, err := store.LeagueSnapshots("a"); err != nil || len(snapshots) != 0 {
+		t.Errorf("snapshots = %v, %v, want none", snapshots, err)
+	}
+	if accounts, err := store.Accounts(); err != nil || len(accounts) != 1 || accounts[0].PUUID != "b" {
+		t.Errorf("accounts = %+v, %v, want b", accounts, err)
+	}
+}
+
+func TestLeagueSnapshots(t *testing.T) {
+	store := openTemp(t)
+	if err := store.SaveAccount(Account{PUUID: "a", Tracked: true}); err != nil {
+		t.Fatal(err)
+	}
+	want := []api.LeagueSnapshot{
+		{Time: 100, Tier: "GOLD", Rank: "II", LeaguePoints: 50, Wins: 10, Losses: 8},
+		{Time: 200, Tier: "GOLD", Rank: "I", LeaguePoints: 0, Wins: 11, Losses: 8},
+	}
+	for _, s := range []api.LeagueSnapshot{want[1], want[0], want[0]} {
+		if err := store.SaveLeagueSnapshot("a", s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if snapshots, err := store.LeagueSnapshots("a"); err != nil || !reflect.DeepEqual(snapshots, want) {
+		t.Errorf("snapshots = %+v, %v, want %+v", snapshots, err, want)
+	}
+	// Snapshots belong to stored accounts
+	if err := store.SaveLeagueSnapshot("unknown", want[0]); err == nil {
+		t.Error("snapshot of an unknown account should fail")
+	}
+}
+
+func TestAnnouncements(t *testing.T) {
+	store := openTemp(t)
+	live := Announcement{MatchID: "EUW1_1", Destination: "discord", Kind: api.LiveAnnouncement, MessageID: "1", Time: 10}
+	report := Announcement{MatchID: "EUW1_1", Destination: "discord", Kind: api.ReportAnnouncement, MessageID: "2", Time: 20}
+	for _, a := range []Announcement{live, report} {
+		if err := store.SaveAnnouncement(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Announcing again replaces the message
+	live.MessageID = "3"
+	if err := store.SaveAnnouncement(live); err != nil {
+		t.Fatal(err)
+	}
+	want := []Announcement{live, report}
+	if announcements, err := store.Announcements("EUW1_1"); err != nil || !reflect.DeepEqual(announcements, want) {
+		t.Errorf("announcements = %+v, %v, want %+v", announcements, err, want)
+	}
+	if announcements, err := store.Announcements("EUW1_2"); err != nil || len(announcements) != 0 {
+		t.Errorf("announcements of another game = %+v, %v", announcements, err)
+	}
+}
+
+func TestGuilds(t *testing.T) {
+	store := openTemp(t)
+	guild := GuildSettings{GuildID: "g", ChannelID: "c", Players: []string{"b", "a"}, Template: "short", Roast: 2, Scoreboard: true, AdminRole: "r"}
+	if err := store.SaveGuild(guild); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveGuild(GuildSettings{GuildID: "h", Template: "full"}); err != nil {
+		t.Fatal(err)
+	}
+	guild.Players = []string{"a", "c", "b"}
+	guild.Card = true
+	if err := store.SaveGuild(guild); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []GuildSettings{guild, {GuildID: "h", Template: "full", Players: []string{}}}
+	if guilds, err := store.Guilds(); err != nil || !reflect.DeepEqual(guilds, want) {
+		t.Errorf("guilds = %+v, %v, want %+v", guilds, err, want)
+	}
+}
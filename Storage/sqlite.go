@@ -0,0 +1,11 @@
+//go:build cgo
+
+package storage
+
+/* The SQLite driver wraps the C library, builds without cgo only have PostgreSQL */
+
+import (
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteSupported = true
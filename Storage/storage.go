@@ -0,0 +1,166 @@
+package storage
+
+/* SQL persistence of players, games, ranks, announcements and guild settings */
+
+import (
+	"sync"
+	"time"
+
+	api "github.com/Nvim/silverstalker/Api"
+)
+
+// Person behind Riot accounts, identified by their Discord user ID
+type Player struct {
+	ID   string
+	Name string
+}
+
+// Riot account, tracked or not anymore
+type Account struct {
+	PUUID      string
+	PlayerID   string // empty when no player is linked
+	GameName   string
+	TagLine    string
+	SummonerID string
+	AccountID  string
+	Tracked    bool
+}
+
+type MatchFilter struct {
+	PUUID string // only the games of this account, all of them if empty
+	api.StatsFilter
+	Limit int // all games when <= 0
+}
+
+// Message posted about a game, the match ID of live games is the one the
+// game will have once it's over
+type Announcement struct {
+	MatchID     string
+	Destination string // name of the notifier
+	Kind        string // api.LiveAnnouncement or api.ReportAnnouncement
+	MessageID   string
+	Time        int64 // epoch seconds
+}
+
+type GuildSettings struct {
+	GuildID    string   `json:"guildId"`
+	ChannelID  string   `json:"channelId"` // where reports are announced, none if empty
+	Players    []string `json:"players"`   // PUUIDs of the players the guild tracks
+	Template   string   `json:"template"`
+	Roast      int      `json:"roast"`
+	Locale     string   `json:"locale,omitempty"`
+	Scoreboard bool     `json:"scoreboard"`
+	Card       bool     `json:"card"`
+	AdminRole  string   `json:"adminRole,omitempty"` // role allowed to run admin commands
+}
+
+type Repository interface {
+	SavePlayer(p Player) error
+	DeletePlayer(id string) error // accounts of the player are unlinked
+	Players() ([]Player, error)
+
+	SaveAccount(a Account) error
+	// Links the account to a.PlayerID, storing it as is if it's unknown
+	LinkAccount(a Account) error
+	UnlinkAccount(puuid string) error
+	// Forgets the account, its rank snapshots and the games where it was the
+	// only tracked account
+	DeleteAccount(puuid string) error
+	Accounts() ([]Account, error)
+
+	// Stores the raw match-v5 JSON of a game, indexed by queue, date and participants
+	SaveMatch(raw []byte) error
+	GetMatch(id string) (*api.Match, error)           // nil if it isn't stored
+	Matches(filter MatchFilter) ([]*api.Match, error) // newest first
+	MatchIDs(puuid string) ([]string, error)          // of the account's stored games
+
+	SaveLeagueSnapshot(puuid string, s api.LeagueSnapshot) error
+	LeagueSnapshots(puuid string) ([]api.LeagueSnapshot, error) // oldest first
+
+	SaveAnnouncement(a Announcement) error
+	Announcements(matchID string) ([]Announcement, error)
+
+	SaveGuild(g GuildSettings) error
+	Guilds() ([]GuildSettings, error)
+
+	Close() error
+}
+
+var (
+	// Where the bot, the poller and the HTTP API persist and read their data,
+	// opened by run and export, see Use
+	Store Repository
+	// SQLite file, or a postgres:// URL
+	DatabaseURL = databaseURL()
+	snapshotMu  sync.Mutex // serializes rank snapshots, so that one isn't saved twice
+)
+
+// Makes the repository the one the bot, the poller and the HTTP API read from
+func Use(repo Repository) {
+	Store = repo
+	api.MatchReader = func(puuid string, filter api.StatsFilter, limit int) ([]*api.Match, error) {
+		return repo.Matches(MatchFilter{PUUID: puuid, StatsFilter: filter, Limit: limit})
+	}
+	api.LeagueReader = repo.LeagueSnapshots
+}
+
+// Account of the player, linked to no one and not tracked
+func AccountOf(p *api.PlayerInfo) Account {
+	return Account{PUUID: p.PUUID, GameName: p.GameName, TagLine: p.TagLine, SummonerID: p.SummonerID, AccountID: p.AccountID}
+}
+
+// Saves the accounts of the tracked players, keeping their links, and marks
+// the other accounts as not tracked anymore
+func SyncAccounts(repo Repository, players []*api.PlayerInfo) error {
+	accounts, err := repo.Accounts()
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]Account, len(accounts))
+	for _, a := range accounts {
+		stored[a.PUUID] = a
+	}
+	for _, p := range players {
+		account := AccountOf(p)
+		account.PlayerID = stored[p.PUUID].PlayerID
+		account.Tracked = true
+		if err := repo.SaveAccount(account); err != nil {
+			return err
+		}
+		delete(stored, p.PUUID)
+	}
+	for _, a := range stored {
+		if a.Tracked {
+			a.Tracked = false
+			if err := repo.SaveAccount(a); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Fetches the player's Solo/Duo rank and stores it if it changed.
+// Returns the new snapshot, nil if the rank didn't change.
+func SaveLeagueSnapshot(repo Repository, puuid string) (*api.LeagueSnapshot, error) {
+	api.ResponseCache.Delete("league/" + puuid)
+	stats, err := api.GetRankedStatsByPuuid(puuid)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	snapshots, err := repo.LeagueSnapshots(puuid)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := api.LeagueSnapshot{Time: time.Now().Unix(), Tier: stats.Tier, Rank: stats.Rank, LeaguePoints: stats.LeaguePoints, Wins: stats.Wins, Losses: stats.Losses}
+	if len(snapshots) > 0 {
+		last := snapshots[len(snapshots)-1]
+		if last.Wins == snapshot.Wins && last.Losses == snapshot.Losses && last.RankValue() == snapshot.RankValue() {
+			return nil, nil
+		}
+	}
+	return &snapshot, repo.SaveLeagueSnapshot(puuid, snapshot)
+}
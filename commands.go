@@ -0,0 +1,385 @@
+package main
+
+/* Subcommands of the CLI */
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	api "github.com/Nvim/silverstalker/Api"
+	bot "github.com/Nvim/silverstalker/Bot"
+	card "github.com/Nvim/silverstalker/Card"
+	export "github.com/Nvim/silverstalker/Export"
+	notify "github.com/Nvim/silverstalker/Notify"
+	poller "github.com/Nvim/silverstalker/Poller"
+	server "github.com/Nvim/silverstalker/Server"
+	storage "github.com/Nvim/silverstalker/Storage"
+)
+
+// Parses flags placed before, after or between positional arguments
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// Flag that can be repeated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Tracked players named by the Riot IDs, all of them when there are none
+func selectPlayers(riotIDs []string) ([]*api.PlayerInfo, error) {
+	if len(riotIDs) == 0 {
+		return api.TrackedPlayers(), nil
+	}
+	players := make([]*api.PlayerInfo, 0, len(riotIDs))
+	for _, riotID := range riotIDs {
+		player := api.FindPlayer(riotID)
+		if player == nil {
+			return nil, errors.New(riotID + " isn't tracked")
+		}
+		players = append(players, player)
+	}
+	return players, nil
+}
+
+// Builds the notifier reports are posted with, the bot session is only
+// needed for discord
+func newNotifier(kind string, path string) (notify.Notifier, error) {
+	switch kind {
+	case "discord":
+		return &notify.Discord{Session: bot.Bot, ChannelID: bot.ChannelID}, nil
+	case "stdout":
+		return &notify.Stdout{}, nil
+	case "file":
+		return &notify.File{Path: path}, nil
+	case "none":
+		// Only the guilds' announcement channels
+		return notify.Multi{}, nil
+	}
+	return notify.FromEnv(kind)
+}
+
+// Comma separated kinds post to every destination at once
+func newNotifiers(kinds []string, path string) (notify.Notifier, error) {
+	notifiers := make(notify.Multi, 0, len(kinds))
+	for _, kind := range kinds {
+		n, err := newNotifier(kind, path)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	if len(notifiers) == 1 {
+		return notifiers[0], nil
+	}
+	return notifiers, nil
+}
+
+func runCommand(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	kind := flags.String("notifier", notify.Kind(), "where reports are posted, comma separated: discord, stdout, file, discord-webhook, webhook, slack, matrix or none (NOTIFIER)")
+	path := flags.String("notify-file", notify.FilePath(), "file reports are appended to with -notifier file (NOTIFY_FILE)")
+	dryRun := flags.Bool("dry-run", false, "print reports instead of posting them, same as -notifier stdout")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *dryRun {
+		*kind = "stdout"
+	}
+	kinds := strings.Split(strings.ReplaceAll(*kind, " ", ""), ",")
+	// Only the discord notifier starts the bot, guilds' announcement channels
+	// come with it. Other notifiers never touch Discord.
+	withBot := slices.Contains(kinds, "discord")
+
+	if err := api.LoadPlayers(); err != nil {
+		return err
+	}
+	fmt.Println(api.PrettyPrint(api.TrackedPlayers()))
+
+	err := api.LoadGroups()
+	if err != nil {
+		return errors.New("Error loading group records: " + err.Error())
+	}
+	if err := api.LoadPrivacy(); err != nil {
+		return errors.New("Error loading privacy settings: " + err.Error())
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	/* Bot Init: */
+	if withBot {
+		bot.BotToken = os.Getenv("BOT_TOKEN")
+		err = bot.Init()
+		if err != nil {
+			return errors.New("Error creating bot: " + err.Error())
+		}
+		if err := bot.LoadGuilds(); err != nil {
+			return errors.New("Error loading guild settings: " + err.Error())
+		}
+		if err := bot.LoadLinks(); err != nil {
+			return errors.New("Error loading linked accounts: " + err.Error())
+		}
+	}
+	poller.Notifier, err = newNotifiers(kinds, *path)
+	if err != nil {
+		return err
+	}
+
+	/* Get players' latest games: */
+	err = poller.Init(api.TrackedPlayers())
+	if err != nil {
+		return errors.New("Error getting player matches: " + err.Error())
+	}
+
+	// Poll each player on its own schedule
+	scheduler := poller.NewScheduler(api.TrackedPlayers())
+	bot.OnPlayersChanged = func(players []*api.PlayerInfo) {
+		scheduler.SetPlayers(players)
+		if err := storage.SyncAccounts(store, players); err != nil {
+			log.Println("Error storing tracked players: " + err.Error())
+		}
+	}
+	go scheduler.Run()
+
+	/* HTTP API Init: */
+	if server.Addr != "" {
+		go func() {
+			log.Println("HTTP server stopped: " + server.Listen().Error())
+		}()
+	}
+
+	if !withBot {
+		// No bot to listen to, run until interrupted
+		log.Println("Posting reports to " + poller.Notifier.Name())
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt)
+		<-c
+		return nil
+	}
+	return bot.Listen()
+}
+
+func reportCommand(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	riotID := flags.String("player", "", "player the report is about, first tracked player of the match by default")
+	scoreboard := flags.Bool("scoreboard", false, "also print the scoreboard of the 10 participants with their current rank")
+	cardPath := flags.String("card", "", "also write the PNG summary card of the player to this file")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("expected a match ID")
+	}
+	if err := api.LoadPlayers(); err != nil {
+		return err
+	}
+
+	match, err := api.GetMatchInfo(positional[0])
+	if err != nil {
+		return err
+	}
+	var player *api.PlayerInfo
+	if *riotID != "" {
+		if player = api.FindPlayer(*riotID); player == nil {
+			player, err = api.ParseRiotID(*riotID)
+			if err != nil {
+				return err
+			}
+			if err := player.GetIDs(); err != nil {
+				return err
+			}
+		}
+	} else if tracked := api.GetTrackedInMatch(match, api.TrackedPlayers()); len(tracked) > 0 {
+		player = tracked[0]
+	} else {
+		return errors.New("no tracked player in match, use -player")
+	}
+
+	msg, err := api.GetMatchDescString(match, player)
+	if err != nil {
+		return err
+	}
+	fmt.Print(msg)
+	if *scoreboard {
+		tracked := append(api.GetTrackedInMatch(match, api.TrackedPlayers()), player)
+		fmt.Print(api.GetScoreboardString(api.GetScoreboard(match, tracked, true)))
+	}
+	if *cardPath != "" {
+		png, err := card.Render(match, player.PUUID, api.DefaultReportOptions)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(*cardPath, png, 0644)
+	}
+	return nil
+}
+
+func backfillCommand(args []string) error {
+	if err := api.LoadPlayers(); err != nil {
+		return err
+	}
+	players, err := selectPlayers(args)
+	if err != nil {
+		return err
+	}
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	for _, p := range players {
+		progress := func(status api.BackfillProgress) {
+			log.Printf("%s: %d listed, %d downloaded, %d failed\n", p.RiotID(), status.Listed, status.Downloaded, status.Failed)
+		}
+		status, err := p.Backfill(progress)
+		if err := storage.ImportMatches(store, p.PUUID); err != nil {
+			log.Println("couldn't store backfilled games of " + p.RiotID() + ": " + err.Error())
+		}
+		if err != nil {
+			return errors.New(p.RiotID() + " interrupted, run again to resume: " + err.Error())
+		}
+		progress(status)
+	}
+	return nil
+}
+
+func playersCommand(args []string) error {
+	if err := api.LoadPlayers(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("expected add, remove or list")
+	}
+
+	switch args[0] {
+	case "list":
+		for _, p := range api.TrackedPlayers() {
+			fmt.Println(p.RiotID() + "\t" + p.PUUID)
+		}
+		return nil
+	case "add", "remove":
+		if len(args) != 2 {
+			return errors.New("expected a Riot ID")
+		}
+		var player *api.PlayerInfo
+		var err error
+		if args[0] == "add" {
+			player, err = api.AddPlayer(args[1], "")
+		} else {
+			player, err = api.RemovePlayer(args[1])
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(args[0] + ": " + player.RiotID())
+		return nil
+	}
+	return errors.New("unknown players command: " + args[0])
+}
+
+// Opens the database, stores the tracked players and the games and ranks only
+// written to files so far, and reads from it from now on
+func openStore() (*storage.SQL, error) {
+	store, err := storage.Open(storage.DatabaseURL)
+	if err != nil {
+		return nil, errors.New("Error opening database: " + err.Error())
+	}
+	players := api.TrackedPlayers()
+	if err := storage.SyncAccounts(store, players); err != nil {
+		store.Close()
+		return nil, errors.New("Error storing tracked players: " + err.Error())
+	}
+	if err := storage.ImportFiles(store, players); err != nil {
+		store.Close()
+		return nil, errors.New("Error importing stored games: " + err.Error())
+	}
+	storage.Use(store)
+	return store, nil
+}
+
+func exportCommand(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	var riotIDs stringList
+	flags.Var(&riotIDs, "player", "only export this player's games, can be repeated")
+	table := flags.String("table", "participants", "table to export: "+strings.Join(export.Tables, ", "))
+	format := flags.String("format", "", "output format: "+strings.Join(export.Formats, ", ")+", guessed from -out, jsonl by default")
+	queue := flags.Int("queue", 0, "only export games of this queue ID")
+	since := flags.String("since", "", "only export games played from this date (YYYY-MM-DD)")
+	until := flags.String("until", "", "only export games played until this date included (YYYY-MM-DD)")
+	out := flags.String("out", "", "output file, stdout by default")
+	if _, err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := api.LoadPlayers(); err != nil {
+		return err
+	}
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	players, err := selectPlayers(riotIDs)
+	if err != nil {
+		return err
+	}
+	filter := export.Filter{Players: api.AllowingPlayers(players, api.ReportAnnouncement, false), StatsFilter: api.StatsFilter{Queue: *queue}}
+	if *since != "" {
+		if filter.From, err = time.Parse(time.DateOnly, *since); err != nil {
+			return err
+		}
+	}
+	if *until != "" {
+		if filter.To, err = api.ParseUntil(*until); err != nil {
+			return err
+		}
+	}
+	if *format == "" {
+		*format = "jsonl"
+		if ext := strings.TrimPrefix(filepath.Ext(*out), "."); slices.Contains(export.Formats, ext) {
+			*format = ext
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return export.Export(w, *table, *format, filter)
+}
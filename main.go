@@ -1,130 +1,65 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"time"
-
-	bot "github.com/Nvim/silverstalker/Bot"
 
 	api "github.com/Nvim/silverstalker/Api"
+	static "github.com/Nvim/silverstalker/Static"
+	storage "github.com/Nvim/silverstalker/Storage"
 )
 
+// Subcommands, each one gets the arguments following its name
+var commands = map[string]func(args []string) error{
+	"run":      runCommand,
+	"report":   reportCommand,
+	"backfill": backfillCommand,
+	"players":  playersCommand,
+	"export":   exportCommand,
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage: silverstalker [options] <command> [arguments]
+
+Commands:
+  run [-dry-run] [-notifier kinds]      start the bot, the poller and the HTTP server
+  report <match-id> [-player riot#tag]  print a match report, -scoreboard adds the lobby, -card out.png writes its card
+  backfill [riot#tag...]                download the whole match history of players
+  players add|remove <riot#tag>         start or stop tracking a player
+  players list                          list tracked players
+  export [-table t] [-out file]         export stored games as CSV, JSON Lines or Parquet
+
+Options:
+`)
+	flag.PrintDefaults()
+}
+
 func main() {
 	// err := godotenv.Load(".envrc")
 	// if err != nil {
 	// 	log.Fatal("Couldn't load .env: ", err)
 	// 	return
 	// }
-	/* Lucas Init: */
-	api.Lucas = new(api.PlayerInfo)
-	lucas := api.Lucas
-	lucas.GameName = "lucxsstbn"
-	lucas.TagLine = "EUW"
-	err := lucas.GetIDs()
-	if err != nil {
-		log.Fatal("Error getting player's IDs: " + err.Error())
-		return
+	cacheDir := flag.String("cache-dir", api.CacheDir, "directory of the cache and local stores (CACHE_DIR)")
+	flag.StringVar(&api.PlayersFile, "players-file", api.PlayersFile, "file listing tracked players")
+	flag.StringVar(&api.GroupsFile, "groups-file", api.GroupsFile, "file storing group records")
+	flag.StringVar(&static.Dir, "static-dir", static.Dir, "static data bundle directory (STATIC_DIR)")
+	flag.StringVar(&storage.DatabaseURL, "database", storage.DatabaseURL, "SQLite file (needs a cgo build) or postgres:// URL used by run (DATABASE_URL)")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *cacheDir != api.CacheDir {
+		api.SetCacheDir(*cacheDir)
 	}
-	fmt.Println(api.PrettyPrint(lucas))
-
-	/* Bot Init: */
-	bot.BotToken = os.Getenv("BOT_TOKEN")
-	err = bot.Init()
-	if err != nil {
-		log.Fatal("Error creating bot: " + err.Error())
-		return
-	}
-
-	/* Get Lucas latest game: */
-	matchIDs, err := lucas.GetLatestMatches()
-	if err != nil {
-		log.Fatal("Error getting player matches: " + err.Error())
-		return
-	}
-	latestId := matchIDs[0]
-	latestMatch, err := api.GetMatchInfo(latestId)
-	if err != nil {
-		log.Fatal("Error getting match info: " + err.Error())
-		return
-	}
-
-	/* Get timestamps: */
-	endTime := latestMatch.Info.GameEndTimestamp
-	eventTimestamp := time.Unix(0, endTime*int64(time.Millisecond))
-
-	log.Println("Latest game ID: ", latestId)
-	log.Println("Latest game ended at: ", eventTimestamp.UTC())
 
-	msg, err := api.GetMatchDescString(latestMatch)
-	if err != nil {
-		log.Fatal("Error gettting match stats: " + err.Error())
+	command, ok := commands[flag.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(2)
 	}
-	log.Println("Stats: " + msg)
-	_ = bot.SendMessage(msg)
-	return
-
-	// sleepDuration := time.Until(eventTimestamp.Add(20 * time.Minute))
-	// if sleepDuration > 0 {
-	// 	log.Println("Sleeping until: ", (time.Now().Add(sleepDuration)).UTC())
-	// 	time.Sleep(sleepDuration)
-	// }
-	// log.Println("Woke up from sleep")
-
-	// Periodic fetching every 10 minutes
-	ticker := time.NewTicker(10 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-
-		/* Fetch Data: */
-		log.Println("Fetching data...")
-		matchIDs, err = lucas.GetLatestMatches()
-		if err != nil {
-			log.Fatal("Error getting player matches: " + err.Error())
-			continue
-		}
-		if matchIDs[0] == latestId {
-			// no new data
-			log.Println("No new match data, latest match ID still is ", latestId)
-			continue
-		}
-		latestId = matchIDs[0]
-		latestMatch, err := api.GetMatchInfo(latestId)
-		if err != nil {
-			log.Fatal("Error getting match info: " + err.Error())
-			continue
-		}
-
-		/* Get timestamps for newest game: */
-		endTime := latestMatch.Info.GameEndTimestamp
-		eventTimestamp := time.Unix(0, endTime*int64(time.Millisecond))
-
-		log.Println("New latest game ID: ", latestId)
-		log.Println("New latest game end: ", eventTimestamp.UTC())
-
-		msg, err := api.GetMatchDescString(latestMatch)
-		if err != nil {
-			log.Fatal("Error gettting match stats: " + err.Error())
-		}
-		log.Println("Stats: " + msg)
-		_ = bot.SendMessage(msg)
-
-		/* Go to sleep: */
-		sleepDuration := time.Until(eventTimestamp.Add(20 * time.Minute))
-		if sleepDuration > 0 {
-			log.Println("Sleeping until: ", (time.Now().Add(sleepDuration)).UTC())
-			time.Sleep(sleepDuration)
-		}
-		log.Println("Woke up from sleep")
-
-		/* Reset Ticket */
-		ticker.Reset(10 * time.Minute)
+	if err := command(flag.Args()[1:]); err != nil {
+		log.Fatal(flag.Arg(0) + ": " + err.Error())
 	}
-
-	// err = bot.Listen()
-	// if err != nil {
-	// 	return
-	// }
 }